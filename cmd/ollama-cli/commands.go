@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// command es un subcomando del modo directo. Solo se reconoce si fits
+// acepta sus argumentos; si no, la línea entera es una pregunta, de modo
+// que "oli read the docs and explain" no se confunde con "oli read main.go".
+type command struct {
+	name  string
+	usage string // línea de uso, sin "oli "
+	help  string // descripción para "oli help <comando>"
+	fits  func(args []string) bool
+	run   func(ctx context.Context, args []string) int
+}
+
+// commands se completa en init porque "help" necesita la lista
+var commands []command
+
+func init() {
+	commands = []command{
+		{
+			name:  "agent",
+			usage: "agent <tarea>",
+			help: `El modelo resuelve la tarea con herramientas: leer, listar y escribir
+archivos, ejecutar comandos (según la política command_*) y las
+herramientas de los servidores MCP. Las escrituras y los comandos piden
+confirmación salvo con --yes; con --format json las preguntas van a la
+salida de errores. Todo lo que sigue a "agent" es la tarea, con o sin
+comillas.`,
+			fits: minArgs(1),
+			run: func(ctx context.Context, args []string) int {
+				return askCmd(ctx, strings.Join(args, " "), true)
+			},
+		},
+		{
+			name:  "run",
+			usage: "run <comando>",
+			help: `Ejecuta un comando en el directorio actual con la política de permisos
+(command_allow, command_confirm, command_deny) y sale con su exit code.
+Solo se reconoce si el comando existe en el PATH; si no, la línea es una
+pregunta.`,
+			fits: func(args []string) bool {
+				if len(args) == 0 {
+					return false
+				}
+				_, err := exec.LookPath(args[0])
+				return err == nil
+			},
+			run: func(ctx context.Context, args []string) int {
+				app := newApp()
+				defer app.Close()
+				return runCmd(ctx, app, strings.Join(args, " "))
+			},
+		},
+		{
+			name:  "commit",
+			usage: `commit ["motivo"]`,
+			help: `Redacta el mensaje de commit (Conventional Commits) para los cambios
+preparados con git add. El motivo opcional orienta al modelo y va entre
+comillas: con más palabras sueltas la línea es una pregunta. Permite
+aceptarlo, editarlo o pedir otro; con --yes se acepta el primero.`,
+			fits: maxArgs(1),
+			run: func(ctx context.Context, args []string) int {
+				app := newApp()
+				defer app.Close()
+				if err := app.Commit(ctx, strings.Join(args, " ")); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					return 1
+				}
+				return 0
+			},
+		},
+		{
+			name:  "review",
+			usage: "review [--format text|json] [--fail-on <severidad>] [base..head]",
+			help: `Revisa con el prompt code-review los cambios sin confirmar o los del
+rango indicado (como en git diff). Sale con código 1 si alguna
+observación alcanza --fail-on (default: review_fail_on) y 2 si falla.`,
+			fits: func(args []string) bool {
+				return len(args) <= 1 || strings.HasPrefix(args[0], "-")
+			},
+			run: reviewCmd,
+		},
+		{
+			name:  "read",
+			usage: "read <archivo>",
+			help:  `Muestra el contenido de un archivo del proyecto.`,
+			fits:  maxArgs(1),
+			run: func(ctx context.Context, args []string) int {
+				if len(args) == 0 {
+					fmt.Println("Uso: oli read <archivo>")
+					return 1
+				}
+				readFileCmd(args[0])
+				return 0
+			},
+		},
+		{
+			name:  "ls",
+			usage: "ls [dir]",
+			help:  `Lista los archivos y carpetas de un directorio (por defecto el actual).`,
+			fits:  maxArgs(1),
+			run: func(ctx context.Context, args []string) int {
+				path := "."
+				if len(args) == 1 {
+					path = args[0]
+				}
+				listDirCmd(path)
+				return 0
+			},
+		},
+		{
+			name:  "history",
+			usage: "history",
+			help:  `Muestra los archivos que oli modificó en este directorio.`,
+			fits:  maxArgs(0),
+			run: func(ctx context.Context, args []string) int {
+				historyCmd()
+				return 0
+			},
+		},
+		{
+			name:  "undo",
+			usage: "undo [n]",
+			help:  `Deshace los últimos n cambios de oli en este directorio (1 por defecto).`,
+			fits: func(args []string) bool {
+				if len(args) == 0 {
+					return true
+				}
+				_, err := strconv.Atoi(args[0])
+				return len(args) == 1 && err == nil
+			},
+			run: func(ctx context.Context, args []string) int {
+				if !undoCmd(args) {
+					return 1
+				}
+				return 0
+			},
+		},
+		{
+			name:  "prompts",
+			usage: "prompts",
+			help:  `Lista los prompts disponibles y de dónde viene cada uno.`,
+			fits:  maxArgs(0),
+			run: func(ctx context.Context, args []string) int {
+				showPrompts()
+				return 0
+			},
+		},
+		{
+			name:  "index",
+			usage: "index build|status|clear",
+			help: `Gestiona el índice semántico del proyecto (embeddings con Ollama, en
+.oli/): build lo crea o actualiza, status muestra su estado y clear lo
+borra.`,
+			fits: subcommands("build", "status", "clear"),
+			run: func(ctx context.Context, args []string) int {
+				return exitCode(indexCmd(ctx, args))
+			},
+		},
+		{
+			name:  "config",
+			usage: "config [show]",
+			help:  `Muestra la configuración efectiva y de dónde viene cada valor.`,
+			fits:  subcommands("show"),
+			run: func(ctx context.Context, args []string) int {
+				return exitCode(configCmd(args))
+			},
+		},
+		{
+			name:  "mcp",
+			usage: "mcp list|serve [--allow-write <patrón>]...",
+			help: `list muestra las herramientas, recursos y prompts de los servidores MCP
+configurados (mcp_servers). serve sirve oli como servidor MCP por stdio;
+--allow-write (repetible) indica los archivos que los clientes pueden
+escribir, además de mcp_serve_write.`,
+			fits: func(args []string) bool {
+				return subcommands("list", "ls")(args) || (len(args) > 0 && args[0] == "serve")
+			},
+			run: func(ctx context.Context, args []string) int {
+				return exitCode(mcpCmd(ctx, args))
+			},
+		},
+		{
+			name:  "sessions",
+			usage: "sessions [list | rm <id>...]",
+			help:  `Lista las sesiones interactivas guardadas o elimina las indicadas.`,
+			fits: func(args []string) bool {
+				return subcommands("list", "ls")(args) || (len(args) > 1 && args[0] == "rm")
+			},
+			run: func(ctx context.Context, args []string) int {
+				sessionsCmd(args)
+				return 0
+			},
+		},
+		{
+			name:  "resume",
+			usage: "resume <id>",
+			help:  `Reanuda una sesión interactiva; acepta un prefijo del id.`,
+			fits:  func(args []string) bool { return len(args) == 1 },
+			run: func(ctx context.Context, args []string) int {
+				resumeCmd(ctx, args[0])
+				return 0
+			},
+		},
+		{
+			name:  "help",
+			usage: "help [comando]",
+			help:  `Muestra la ayuda general o la de un comando.`,
+			fits: func(args []string) bool {
+				return len(args) == 0 || (len(args) == 1 && lookupCommand(args[0]) != nil)
+			},
+			run: func(ctx context.Context, args []string) int {
+				if len(args) == 0 {
+					showHelp()
+					return 0
+				}
+				lookupCommand(args[0]).showHelp()
+				return 0
+			},
+		},
+	}
+}
+
+// lookupCommand busca un subcomando por nombre
+func lookupCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// matchCommand devuelve el subcomando que pide args, o nil si args es una
+// pregunta: la primera palabra no es un comando o el resto no encaja
+func matchCommand(args []string) *command {
+	if len(args) == 0 {
+		return nil
+	}
+	c := lookupCommand(args[0])
+	if c == nil {
+		return nil
+	}
+	if rest := args[1:]; !isHelp(rest) && c.fits != nil && !c.fits(rest) {
+		return nil
+	}
+	return c
+}
+
+// dispatch ejecuta el subcomando de args si lo hay y sus argumentos
+// encajan; devuelve false si args es una pregunta
+func dispatch(ctx context.Context, args []string) (code int, ok bool) {
+	c := matchCommand(args)
+	if c == nil {
+		return 0, false
+	}
+	rest := args[1:]
+	if isHelp(rest) {
+		c.showHelp()
+		return 0, true
+	}
+	return c.run(ctx, rest), true
+}
+
+// isHelp indica si los argumentos de un comando piden su ayuda
+func isHelp(args []string) bool {
+	return len(args) == 1 && (args[0] == "-h" || args[0] == "--help")
+}
+
+func (c *command) showHelp() {
+	fmt.Printf("\n Uso: oli %s\n\n", c.usage)
+	for _, line := range strings.Split(c.help, "\n") {
+		fmt.Printf("   %s\n", line)
+	}
+	fmt.Println("\n Los flags globales (oli help) van antes del comando.")
+	fmt.Println()
+}
+
+func minArgs(n int) func([]string) bool {
+	return func(args []string) bool { return len(args) >= n }
+}
+
+func maxArgs(n int) func([]string) bool {
+	return func(args []string) bool { return len(args) <= n }
+}
+
+// subcommands acepta ningún argumento o uno solo de names
+func subcommands(names ...string) func([]string) bool {
+	return func(args []string) bool {
+		return len(args) == 0 || (len(args) == 1 && slices.Contains(names, args[0]))
+	}
+}
+
+// exitCode muestra err, si lo hay, y devuelve el código de salida
+func exitCode(err error) int {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
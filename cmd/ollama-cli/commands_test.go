@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMatchCommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string // "" = es una pregunta
+	}{
+		{[]string{"explica", "main.go"}, ""},
+		{[]string{"read", "main.go"}, "read"},
+		{[]string{"read", "the", "docs", "and", "explain"}, ""},
+		{[]string{"read"}, "read"},
+		{[]string{"ls"}, "ls"},
+		{[]string{"ls", "internal"}, "ls"},
+		{[]string{"ls", "the", "files", "in", "src"}, ""},
+		{[]string{"read", "--help"}, "read"},
+		{[]string{"agent"}, ""},
+		{[]string{"agent", "arregla", "el", "test"}, "agent"},
+		{[]string{"run", os.Args[0], "-test.v"}, "run"},
+		{[]string{"run", "no-such-command-oli", "x"}, ""},
+		{[]string{"run"}, ""},
+		{[]string{"commit"}, "commit"},
+		{[]string{"commit", "arregla el login"}, "commit"},
+		{[]string{"commit", "the", "staged", "changes"}, ""},
+		{[]string{"review"}, "review"},
+		{[]string{"review", "main..HEAD"}, "review"},
+		{[]string{"review", "--fail-on", "high", "main..HEAD"}, "review"},
+		{[]string{"review", "this", "function"}, ""},
+		{[]string{"history"}, "history"},
+		{[]string{"history", "of", "rome"}, ""},
+		{[]string{"undo"}, "undo"},
+		{[]string{"undo", "3"}, "undo"},
+		{[]string{"undo", "everything"}, ""},
+		{[]string{"index", "build"}, "index"},
+		{[]string{"index", "the", "repo"}, ""},
+		{[]string{"config"}, "config"},
+		{[]string{"config", "show"}, "config"},
+		{[]string{"config", "nginx"}, ""},
+		{[]string{"mcp", "list"}, "mcp"},
+		{[]string{"mcp", "serve", "--allow-write", "docs/**"}, "mcp"},
+		{[]string{"mcp", "is", "a", "protocol"}, ""},
+		{[]string{"sessions", "rm", "abc"}, "sessions"},
+		{[]string{"sessions", "rm"}, ""},
+		{[]string{"resume", "abc"}, "resume"},
+		{[]string{"resume"}, ""},
+		{[]string{"help", "review"}, "help"},
+		{[]string{"help", "me", "write", "tests"}, ""},
+		{[]string{"help", "explain"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if c := matchCommand(tt.args); c != nil {
+			got = c.name
+		}
+		if got != tt.want {
+			t.Errorf("matchCommand(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
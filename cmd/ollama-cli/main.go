@@ -3,13 +3,23 @@ package main
 import (
 	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"ollama-cli/internal/cli"
 	"ollama-cli/internal/config"
+	"ollama-cli/internal/journal"
+	"ollama-cli/internal/llm"
+	"ollama-cli/internal/review"
+	"ollama-cli/internal/session"
 	"ollama-cli/internal/tools"
 )
 
@@ -17,58 +27,308 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
-	// Verificar comandos especiales
-	if len(os.Args) >= 2 {
-		switch os.Args[1] {
-		case "prompts":
-			showPrompts()
-			return
-		case "help", "--help", "-h":
-			showHelp()
-			return
-		case "read":
-			if len(os.Args) >= 3 {
-				readFileCmd(os.Args[2])
-			} else {
-				fmt.Println("Uso: oli read <archivo>")
-			}
-			return
-		case "ls":
-			path := "."
-			if len(os.Args) >= 3 {
-				path = os.Args[2]
+	openJournal()
+
+	f, args, literal, err := parseArgs(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		showHelp()
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprint(os.Stderr, usage)
+		fmt.Fprintln(os.Stderr, "Ver oli help para la lista de flags y comandos.")
+		os.Exit(2)
+	}
+	flags = f
+	tools.SetAssumeYes(flags.yes)
+
+	wd, _ := os.Getwd()
+	if cfg, err = loadConfig(wd); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	setupWorkspace()
+
+	// Un subcomando solo se reconoce si sus argumentos encajan; tras "--"
+	// todo es la pregunta
+	if len(args) >= 1 && !literal {
+		if code, ok := dispatch(ctx, args); ok {
+			if code != 0 {
+				os.Exit(code)
 			}
-			listDirCmd(path)
 			return
 		}
 	}
 
+	// Si hay argumentos, ejecutar una sola vez
+	if len(args) >= 1 {
+		if code := askCmd(ctx, strings.Join(args, " "), false); code != 0 {
+			os.Exit(code)
+		}
+		return
+	}
+	if flags.format != "text" {
+		fmt.Fprintln(os.Stderr, "Error: --format json requiere una pregunta (o agent, review)")
+		os.Exit(2)
+	}
+
 	// Crear app
-	var app *cli.App
-	promptName := os.Getenv("OLI_PROMPT")
+	app := newApp()
+	defer app.Close()
+
+	// Modo interactivo
+	store := openSessionStore()
+	var sess *session.Session
+	if store != nil {
+		sess = store.New(app.GetModel(), wd)
+	}
+	if fileJournal != nil && sess != nil {
+		fileJournal.SetSession(sess.ID)
+	}
+	runInteractive(ctx, app, store, sess)
+}
+
+// cfg es la configuración efectiva: archivos, entorno y flags
+var cfg *config.Config
+
+// globalFlags son los flags globales de la línea de comandos
+type globalFlags struct {
+	model     string      // --model, con prioridad sobre el modelo del prompt
+	prompt    string      // --prompt
+	providers []string    // --providers
+	format    string      // --format: text o json
+	yes       bool        // --yes
+	options   llm.Options // opciones de generación, también sobre las de cada prompt
+
+	// settings son las claves de la configuración que fijan los flags, en
+	// orden; loadConfig las aplica como última capa
+	settings []flagSetting
+}
+
+// flagSetting es una clave de la configuración fijada por un flag
+type flagSetting struct {
+	key   string
+	value any
+	flag  string
+}
+
+// flags son los flags globales de esta ejecución
+var flags = globalFlags{format: "text"}
+
+// loadConfig lee la configuración de workDir y le aplica flags.settings
+func loadConfig(workDir string) (*config.Config, error) {
+	c, err := config.Load(workDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range flags.settings {
+		key := s.key
+		if key == "url" {
+			// --url sigue al backend efectivo
+			key = "ollama_url"
+			if c.Backend == "openai" {
+				key = "openai_url"
+			}
+		}
+		if err := c.Set(key, s.value, "--"+s.flag); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// usage es el resumen que se muestra ante un flag desconocido
+const usage = `Uso: oli [flags] [--] <pregunta>
+     oli [flags] <comando> [argumentos]   (oli help <comando>)
+     oli                                  modo interactivo
+`
+
+// parseArgs procesa los flags globales, que van antes de la pregunta o del
+// comando, y devuelve el resto de los argumentos. literal indica que se usó
+// "--": el resto es la pregunta aunque empiece por el nombre de un
+// comando. Con -h o --help devuelve flag.ErrHelp.
+func parseArgs(args []string) (f globalFlags, rest []string, literal bool, err error) {
+	fs := flag.NewFlagSet("oli", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // main muestra el error y el resumen de uso
+
+	fs.StringVar(&f.model, "model", "", "modelo a usar")
+	fs.StringVar(&f.prompt, "prompt", "", "prompt a usar (ver oli prompts)")
+	url := fs.String("url", "", "URL del servidor (ollama_url u openai_url según el backend)")
+	fs.Func("providers", "proveedores de contexto: git,filesystem; -index desactiva uno; none ninguno", func(v string) error {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				f.providers = append(f.providers, name)
+			}
+		}
+		return nil
+	})
+	maxFiles := fs.Int("max-files", 0, "máximo de archivos del contexto")
+	fs.StringVar(&f.format, "format", "text", "formato de salida: text o json")
+	allowOutside := fs.Bool("allow-outside", false, "permitir leer y escribir fuera del proyecto")
+	fs.BoolVar(&f.yes, "yes", false, "aceptar todas las confirmaciones")
+	fs.BoolVar(&f.yes, "y", false, "igual que --yes")
+
+	temperature := fs.Float64("temperature", 0, "temperatura de muestreo")
+	topP := fs.Float64("top-p", 0, "muestreo nucleus (top_p)")
+	seed := fs.Int("seed", 0, "semilla para respuestas reproducibles")
+	fs.IntVar(&f.options.NumCtx, "num-ctx", 0, "ventana de contexto en tokens")
+	fs.Func("stop", "secuencia de parada (repetible)", func(v string) error {
+		f.options.Stop = append(f.options.Stop, v)
+		return nil
+	})
+
+	diff := fs.Bool("diff", false, "incluir siempre el diff de git")
+	base := fs.String("base", "", "incluir también el diff <ref>...HEAD")
+
+	if err := fs.Parse(args); err != nil {
+		return f, nil, false, err
+	}
+	if f.format != "text" && f.format != "json" {
+		return f, nil, false, fmt.Errorf("formato desconocido %q (text o json)", f.format)
+	}
+
+	// Solo se sobrescriben las opciones indicadas explícitamente
+	set := func(key string, value any, name string) {
+		f.settings = append(f.settings, flagSetting{key, value, name})
+	}
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "model":
+			set("model", f.model, fl.Name)
+		case "url":
+			set("url", *url, fl.Name)
+		case "max-files":
+			set("max_files", *maxFiles, fl.Name)
+		case "allow-outside":
+			set("allow_outside", *allowOutside, fl.Name)
+		case "temperature":
+			f.options.Temperature = temperature
+			set("options.temperature", *temperature, fl.Name)
+		case "top-p":
+			f.options.TopP = topP
+			set("options.top_p", *topP, fl.Name)
+		case "seed":
+			f.options.Seed = seed
+			set("options.seed", *seed, fl.Name)
+		case "num-ctx":
+			set("options.num_ctx", f.options.NumCtx, fl.Name)
+		case "stop":
+			set("options.stop", f.options.Stop, fl.Name)
+		case "diff":
+			if *diff {
+				set("git_diff", "always", fl.Name)
+			}
+		case "base":
+			set("git_diff_base", *base, fl.Name)
+		}
+	})
+
+	rest = fs.Args()
+	consumed := len(args) - len(rest)
+	literal = consumed > 0 && args[consumed-1] == "--"
+	return f, rest, literal, nil
+}
+
+// newApp crea la app con la configuración efectiva y el prompt de --prompt
+// u OLI_PROMPT
+func newApp() *cli.App {
+	promptName := flags.prompt
+	if promptName == "" {
+		promptName = os.Getenv("OLI_PROMPT")
+	}
+	app, err := setupApp(promptName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	return app
+}
+
+// setupApp crea la app con el prompt indicado (vacío = default) y le aplica
+// los flags que tienen prioridad sobre el prompt: opciones, modelo y
+// proveedores
+func setupApp(promptName string) (*cli.App, error) {
+	app := cli.New(cfg)
 	if promptName != "" {
-		app = cli.NewWithPrompt(promptName)
-	} else {
-		app = cli.New()
+		var err error
+		if app, err = cli.NewWithPrompt(cfg, promptName); err != nil {
+			return nil, err
+		}
+	}
+	app.SetOptions(flags.options)
+	if flags.model != "" {
+		app.SetModel(flags.model)
+	}
+	if len(flags.providers) > 0 {
+		if err := app.SetProviders(flags.providers); err != nil {
+			return nil, err
+		}
 	}
+	return app, nil
+}
 
-	// Si hay argumentos, ejecutar una sola vez
-	if len(os.Args) >= 2 {
-		task := strings.Join(os.Args[1:], " ")
-		if err := app.Run(ctx, task); err != nil {
+// answerJSON es la salida de una pregunta con --format json
+type answerJSON struct {
+	Model    string `json:"model"`
+	Prompt   string `json:"prompt"`
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+	Context  string `json:"context,omitempty"`
+}
+
+// askCmd responde una pregunta única (o una tarea en modo agente) y
+// devuelve el código de salida. Con --format json la respuesta no se
+// muestra en streaming ni se ofrece aplicar ediciones: se imprime un
+// objeto JSON al terminar.
+func askCmd(ctx context.Context, task string, agent bool) int {
+	app := newApp()
+	defer app.Close()
+
+	if flags.format == "json" {
+		// Las confirmaciones del agente no deben mezclarse con el JSON
+		tools.SetPromptOutput(os.Stderr)
+		answer := app.Answer
+		if agent {
+			answer = app.AnswerAgent
+		}
+		text, err := answer(ctx, task)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			return 1
 		}
-		return
+		out, err := json.MarshalIndent(answerJSON{
+			Model:    app.GetModel(),
+			Prompt:   app.Prompt(),
+			Question: task,
+			Answer:   text,
+			Context:  app.ContextSummary(),
+		}, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(out))
+		return 0
 	}
 
-	// Modo interactivo
-	runInteractive(ctx, app)
+	run := app.Run
+	if agent {
+		run = app.RunAgent
+	}
+	if err := run(ctx, task); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
 }
 
-func runInteractive(ctx context.Context, app *cli.App) {
+func runInteractive(ctx context.Context, app *cli.App, store *session.Store, sess *session.Session) {
 	fmt.Printf("\n oli (%s)\n", app.GetModel())
-	fmt.Println(" Comandos: salir | help | ls [dir] | read <archivo> | write <archivo>\n")
+	if sess != nil {
+		fmt.Printf(" Sesión: %s\n", sess.ID)
+	}
+	fmt.Print(" Comandos: salir | help | nueva | ls [dir] | read <archivo> | write <archivo>\n\n")
 
 	scanner := bufio.NewScanner(os.Stdin)
 
@@ -93,20 +353,32 @@ func runInteractive(ctx context.Context, app *cli.App) {
 		}
 
 		// Procesar comandos especiales
-		if handled := handleCommand(input); handled {
+		if handled := handleCommand(app, input); handled {
+			continue
+		}
+
+		if command, ok := strings.CutPrefix(input, "run "); ok {
+			runCmd(ctx, app, command)
 			continue
 		}
 
-		// Enviar al modelo
-		if err := app.Run(ctx, input); err != nil {
+		// Enviar al modelo; "agent <tarea>" le permite usar herramientas
+		run := app.Run
+		if task, ok := strings.CutPrefix(input, "agent "); ok {
+			run = app.RunAgent
+			input = strings.TrimSpace(task)
+		}
+		if err := run(ctx, input); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		} else {
+			saveSession(store, sess, app)
 		}
 
 		fmt.Println()
 	}
 }
 
-func handleCommand(input string) bool {
+func handleCommand(app *cli.App, input string) bool {
 	parts := strings.Fields(input)
 	if len(parts) == 0 {
 		return false
@@ -128,6 +400,31 @@ func handleCommand(input string) bool {
 		showPrompts()
 		return true
 
+	case "prompt", "/prompt":
+		if len(parts) < 2 {
+			fmt.Printf(" Prompt actual: %s (modelo %s)\n", app.Prompt(), app.GetModel())
+			return true
+		}
+		if err := app.UsePrompt(parts[1]); err != nil {
+			fmt.Printf(" Error: %v\n", err)
+			return true
+		}
+		fmt.Printf(" Prompt: %s (modelo %s)\n", app.Prompt(), app.GetModel())
+		return true
+
+	case "history":
+		historyCmd()
+		return true
+
+	case "undo":
+		undoCmd(parts[1:])
+		return true
+
+	case "nueva", "reset":
+		app.Reset()
+		fmt.Println(" Conversación reiniciada")
+		return true
+
 	case "ls":
 		path := "."
 		if len(parts) >= 2 {
@@ -162,7 +459,11 @@ func handleCommand(input string) bool {
 			if err := os.Chdir(parts[1]); err != nil {
 				fmt.Printf(" Error: %v\n", err)
 			} else {
+				// El usuario cambió de proyecto: la raíz lo acompaña
 				wd, _ := os.Getwd()
+				if err := tools.SetWorkspace(wd); err != nil {
+					fmt.Printf(" Aviso: %v\n", err)
+				}
 				fmt.Println(wd)
 			}
 		}
@@ -172,6 +473,415 @@ func handleCommand(input string) bool {
 	return false
 }
 
+// openSessionStore abre el almacén de sesiones; si no se puede, el modo
+// interactivo sigue funcionando sin guardar.
+func openSessionStore() *session.Store {
+	dir, err := session.DefaultDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Aviso: sesiones desactivadas: %v\n", err)
+		return nil
+	}
+	return session.NewStore(dir)
+}
+
+// saveSession guarda el estado actual de la conversación
+func saveSession(store *session.Store, sess *session.Session, app *cli.App) {
+	if store == nil || sess == nil {
+		return
+	}
+	if wd, err := os.Getwd(); err == nil {
+		sess.WorkDir = wd
+	}
+	sess.Model = app.GetModel()
+	sess.Messages = app.History()
+	sess.ContextSummary = app.ContextSummary()
+	if err := store.Save(sess); err != nil {
+		fmt.Fprintf(os.Stderr, "Aviso: no se pudo guardar la sesión: %v\n", err)
+	}
+}
+
+func sessionsCmd(args []string) {
+	store := openSessionStore()
+	if store == nil {
+		os.Exit(1)
+	}
+
+	sub := "list"
+	if len(args) >= 1 {
+		sub = args[0]
+	}
+
+	switch sub {
+	case "list", "ls":
+		sessions, err := store.List()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(sessions) == 0 {
+			fmt.Println(" No hay sesiones guardadas")
+			return
+		}
+		fmt.Println()
+		for _, s := range sessions {
+			fmt.Printf("  %s  %s  %-20s %3d turnos  %s\n",
+				s.ID, s.UpdatedAt.Format("2006-01-02 15:04"), s.Model, s.Turns(), s.WorkDir)
+			if title := s.Title(); title != "" {
+				fmt.Printf("      %s\n", title)
+			}
+		}
+		fmt.Println()
+
+	case "rm":
+		if len(args) < 2 {
+			fmt.Println("Uso: oli sessions rm <id>...")
+			os.Exit(1)
+		}
+		failed := false
+		for _, id := range args[1:] {
+			removed, err := store.Remove(id)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				failed = true
+				continue
+			}
+			fmt.Printf(" Sesión eliminada: %s\n", removed)
+		}
+		if failed {
+			os.Exit(1)
+		}
+
+	default:
+		fmt.Println("Uso: oli sessions [list | rm <id>...]")
+		os.Exit(1)
+	}
+}
+
+func resumeCmd(ctx context.Context, id string) {
+	store := openSessionStore()
+	if store == nil {
+		os.Exit(1)
+	}
+
+	sess, err := store.Load(id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.Chdir(sess.WorkDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Aviso: no se pudo volver a %s: %v\n", sess.WorkDir, err)
+	} else {
+		// La configuración del proyecto es la de la sesión, no la del
+		// directorio desde el que se reanuda
+		if cfg, err = loadConfig(sess.WorkDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		setupWorkspace()
+	}
+
+	app := newApp()
+	defer app.Close()
+	if sess.Model != "" && flags.model == "" {
+		app.SetModel(sess.Model)
+	}
+	app.SetHistory(sess.Messages)
+	if fileJournal != nil {
+		fileJournal.SetSession(sess.ID)
+	}
+
+	fmt.Printf("\n Reanudando sesión %s (%d turnos)\n", sess.ID, sess.Turns())
+	if sess.ContextSummary != "" {
+		fmt.Printf(" Último contexto: %s\n", sess.ContextSummary)
+	}
+	runInteractive(ctx, app, store, sess)
+}
+
+// setupWorkspace limita las herramientas de archivos al directorio actual.
+// allow_outside (--allow-outside, OLI_ALLOW_OUTSIDE=1) desactiva la
+// restricción de forma explícita.
+func setupWorkspace() {
+	wd, err := os.Getwd()
+	if err == nil {
+		err = tools.SetWorkspace(wd)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Aviso: no se pudo fijar el espacio de trabajo: %v\n", err)
+	}
+	tools.AllowOutsideWorkspace(cfg.AllowOutside)
+}
+
+// fileJournal registra las escrituras de oli para poder deshacerlas
+var fileJournal *journal.Journal
+
+// openJournal activa el historial de escrituras; si no se puede abrir, oli
+// sigue funcionando pero sus cambios no se podrán deshacer.
+func openJournal() {
+	dir, err := journal.DefaultDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Aviso: historial de cambios desactivado: %v\n", err)
+		return
+	}
+	fileJournal = journal.New(dir)
+	tools.SetJournal(fileJournal)
+}
+
+// journalEntries devuelve las escrituras registradas dentro del directorio
+// actual, de la más antigua a la más reciente.
+func journalEntries() ([]journal.Entry, error) {
+	if fileJournal == nil {
+		return nil, fmt.Errorf("historial de cambios desactivado")
+	}
+	all, err := fileJournal.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	// Las rutas registradas ya tienen los enlaces simbólicos resueltos
+	wd, err := tools.Resolve(".")
+	if err != nil {
+		return nil, err
+	}
+	var entries []journal.Entry
+	for _, e := range all {
+		if strings.HasPrefix(e.Path, wd+string(os.PathSeparator)) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func historyCmd() {
+	entries, err := journalEntries()
+	if err != nil {
+		fmt.Printf(" Error: %v\n", err)
+		return
+	}
+	if len(entries) == 0 {
+		fmt.Println(" No hay cambios registrados en este directorio")
+		return
+	}
+
+	wd, _ := tools.Resolve(".")
+	fmt.Println()
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		action := "modificado"
+		if !e.Existed {
+			action = "creado"
+		}
+		rel, _ := filepath.Rel(wd, e.Path)
+		fmt.Printf("  %3d  %s  %-10s  %s", len(entries)-i, e.Time.Format("2006-01-02 15:04:05"), action, rel)
+		if e.SessionID != "" {
+			fmt.Printf("  [%s]", e.SessionID)
+		}
+		fmt.Println()
+	}
+	fmt.Println("\n Deshacer los últimos n cambios: undo [n]")
+	fmt.Println()
+}
+
+// undoCmd deshace los últimos n cambios (1 por defecto) del directorio actual
+func undoCmd(args []string) bool {
+	n := 1
+	if len(args) >= 1 {
+		v, err := strconv.Atoi(args[0])
+		if err != nil || v < 1 {
+			fmt.Println(" Uso: undo [n]")
+			return false
+		}
+		n = v
+	}
+
+	entries, err := journalEntries()
+	if err != nil {
+		fmt.Printf(" Error: %v\n", err)
+		return false
+	}
+	if len(entries) == 0 {
+		fmt.Println(" No hay cambios que deshacer")
+		return true
+	}
+	n = min(n, len(entries))
+
+	// Del más reciente al más antiguo para que cada archivo vuelva a su estado original
+	for i := len(entries) - 1; i >= len(entries)-n; i-- {
+		e := entries[i]
+		err := fileJournal.Undo(e, false)
+		if errors.Is(err, journal.ErrChanged) {
+			// Restaurar perdería lo que el usuario editó después de oli
+			if !tools.AskConfirmation(fmt.Sprintf("%s cambió después de que oli lo escribiera. ¿Deshacer igualmente y perder esos cambios?", e.Path)) {
+				fmt.Println(" Cancelado")
+				return false
+			}
+			err = fileJournal.Undo(e, true)
+		}
+		if err != nil {
+			fmt.Printf(" Error: %v\n", err)
+			return false
+		}
+		if e.Existed {
+			fmt.Printf(" Restaurado: %s\n", e.Path)
+		} else {
+			fmt.Printf(" Eliminado (lo había creado oli): %s\n", e.Path)
+		}
+	}
+	return true
+}
+
+// reviewCmd revisa los cambios de un rango y devuelve el código de salida:
+// 0 sin observaciones graves, 1 si alguna alcanza --fail-on, 2 si falló
+func reviewCmd(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("oli review", flag.ContinueOnError)
+	format := fs.String("format", flags.format, "formato de salida: text o json")
+	failOn := fs.String("fail-on", cfg.ReviewFailOn, "severidad que hace fallar: critical, high, medium, low, info o none")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Uso: oli review [--format text|json] [--fail-on <severidad>] [base..head]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 1 || (*format != "text" && *format != "json") {
+		fs.Usage()
+		return 2
+	}
+
+	threshold := review.Severity(-1)
+	if *failOn != "none" {
+		s, err := review.ParseSeverity(*failOn)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		threshold = s
+	}
+
+	app, err := setupApp("code-review")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	findings, err := app.Review(ctx, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if *format == "json" {
+		out, err := review.FormatJSON(findings)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		fmt.Print(out)
+	} else {
+		fmt.Print(review.FormatText(findings))
+	}
+
+	if threshold >= 0 {
+		if failing := review.AtLeast(findings, threshold); len(failing) > 0 {
+			fmt.Fprintf(os.Stderr, "%d observación(es) de severidad %s o mayor\n", len(failing), threshold)
+			return 1
+		}
+	}
+	return 0
+}
+
+// indexCmd gestiona el índice semántico del proyecto actual
+func indexCmd(ctx context.Context, args []string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	sub := "status"
+	if len(args) > 0 {
+		sub = args[0]
+	}
+	if sub != "build" && sub != "status" && sub != "clear" {
+		return fmt.Errorf("uso: oli index build|status|clear")
+	}
+
+	app := newApp()
+	defer app.Close()
+	switch sub {
+	case "build":
+		return app.BuildIndex(ctx, wd)
+	case "status":
+		return app.IndexStatus(ctx, wd)
+	}
+	return app.ClearIndex(wd)
+}
+
+// configCmd muestra la configuración efectiva
+func configCmd(args []string) error {
+	if len(args) > 0 && args[0] != "show" {
+		return fmt.Errorf("uso: oli config show")
+	}
+
+	files := cfg.Files()
+	if len(files) == 0 {
+		fmt.Printf("\n Sin archivos de configuración (se buscan %s y .oli/config.toml)\n\n", filepath.Join(config.UserDir(), "config.toml"))
+	} else {
+		fmt.Printf("\n Archivos: %s\n\n", strings.Join(files, ", "))
+	}
+	cfg.Show(os.Stdout)
+	fmt.Println()
+	return nil
+}
+
+// mcpCmd gestiona los servidores MCP configurados
+func mcpCmd(ctx context.Context, args []string) error {
+	sub := "list"
+	if len(args) > 0 {
+		sub = args[0]
+	}
+	switch sub {
+	case "list", "ls":
+		app := newApp()
+		defer app.Close()
+		app.ListMCP(ctx)
+		return nil
+	case "serve":
+		fs := flag.NewFlagSet("oli mcp serve", flag.ContinueOnError)
+		var allowWrite []string
+		fs.Func("allow-write", "patrón de archivos que los clientes pueden escribir (repetible)", func(v string) error {
+			allowWrite = append(allowWrite, v)
+			return nil
+		})
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		app := newApp()
+		defer app.Close()
+		return app.ServeMCP(ctx, os.Stdin, os.Stdout, allowWrite)
+	}
+	return fmt.Errorf("uso: oli mcp list|serve")
+}
+
+// runCmd ejecuta un comando con la política de permisos y devuelve su exit code
+func runCmd(ctx context.Context, app *cli.App, command string) int {
+	result, err := tools.RunCommand(ctx, command, app.CommandOptions())
+	if err != nil {
+		fmt.Printf(" Error: %v\n", err)
+		return 1
+	}
+	fmt.Print(result.Stdout)
+	fmt.Fprint(os.Stderr, result.Stderr)
+	if result.TimedOut {
+		fmt.Println(" Tiempo límite agotado")
+	}
+	if result.ExitCode != 0 {
+		fmt.Printf(" exit code: %d\n", result.ExitCode)
+	}
+	return result.ExitCode
+}
+
 func readFileCmd(path string) {
 	content, err := tools.ReadFile(path)
 	if err != nil {
@@ -223,15 +933,29 @@ func writeFileCmd(path string) {
 func showPrompts() {
 	fmt.Println("\n Prompts disponibles:")
 	fmt.Println(" ────────────────────")
-	for name := range config.Prompts {
-		fmt.Printf("   • %s\n", name)
+	for _, p := range cfg.Profiles() {
+		var details []string
+		if p.Model != "" {
+			details = append(details, "modelo "+p.Model)
+		}
+		if len(p.Providers) > 0 {
+			details = append(details, "contexto "+strings.Join(p.Providers, ","))
+		}
+		if p.Language != "" {
+			details = append(details, "idioma "+p.Language)
+		}
+		line := fmt.Sprintf("   • %-14s %s", p.Name, p.Source)
+		if len(details) > 0 {
+			line += " [" + strings.Join(details, "; ") + "]"
+		}
+		fmt.Println(line)
 	}
-	fmt.Println("\n Uso: OLI_PROMPT=code-review oli")
-	fmt.Println(" Editar: internal/config/config.go\n")
+	fmt.Println("\n Uso: oli --prompt code-review  (o /prompt <nombre> en modo interactivo)")
+	fmt.Printf(" Nuevos: %s/*.md o .oli/prompts/*.md\n\n", filepath.Join(config.UserDir(), "prompts"))
 }
 
 func showHelp() {
-	fmt.Println(`
+	fmt.Print(`
  oli - Asistente de código con Ollama
 
  MODO INTERACTIVO:
@@ -240,6 +964,12 @@ func showHelp() {
  COMANDOS EN MODO INTERACTIVO:
    help                    Esta ayuda
    prompts                 Ver prompts disponibles
+   /prompt <nombre>        Cambiar de prompt (sin nombre: ver el actual)
+   nueva                   Olvidar la conversación y empezar de cero
+   agent <tarea>           El modelo usa herramientas (archivos y comandos)
+   run <comando>           Ejecutar un comando (según permisos)
+   history                 Ver archivos modificados por oli
+   undo [n]                Deshacer los últimos n cambios
    ls [dir]                Listar archivos
    read <archivo>          Leer contenido de archivo
    write <archivo>         Escribir archivo (con confirmación)
@@ -248,21 +978,119 @@ func showHelp() {
    salir                   Salir
 
  MODO DIRECTO:
-   oli <pregunta>          Pregunta única
+   oli [flags] <pregunta>  Pregunta única
+   oli [flags] -- <texto>  Todo lo que sigue a -- es la pregunta
+   oli agent <tarea>       Tarea en modo agente (el resto de la línea)
+   oli run <comando>       Ejecutar un comando del PATH (según permisos)
+   oli history             Ver archivos modificados por oli
+   oli undo [n]            Deshacer los últimos n cambios
    oli read <archivo>      Leer archivo
    oli ls [dir]            Listar directorio
+   oli help <comando>      Ayuda de un comando (o oli <comando> --help)
+   Un comando solo se reconoce si sus argumentos encajan: "oli read
+   main.go" lee el archivo, "oli read the docs and explain" es una pregunta
+
+ COMMITS:
+   oli commit ["motivo"]   Redactar el mensaje (Conventional Commits) para
+                           los cambios preparados con git add; permite
+                           aceptarlo, editarlo o pedir otro
+
+ REVISIÓN DE CÓDIGO (prompt code-review):
+   oli review              Revisar los cambios sin confirmar
+   oli review main..HEAD   Revisar un rango (como en git diff)
+   --format json           Salida en JSON
+   --fail-on <severidad>   Salir con código 1 si hay observaciones de esa
+                           severidad o mayor (default: high; none = nunca)
 
- CONFIGURACIÓN:
-   Editar: internal/config/config.go
+ ÍNDICE SEMÁNTICO (embeddings con Ollama, en .oli/ del proyecto):
+   oli index build         Crear o actualizar el índice (solo lo que cambió)
+   oli index status        Ver modelo, tamaño y archivos pendientes
+   oli index clear         Borrar el índice
+
+ SERVIDORES MCP ([[mcp_servers]] en la configuración):
+   oli mcp list            Ver herramientas, recursos y prompts de cada
+                           servidor. Sus recursos se agregan al contexto y
+                           sus herramientas al modo agente (servidor_tool)
+   oli mcp serve           Servir oli como servidor MCP por stdio: recursos
+                           oli://context/filesystem y oli://context/git
+                           (?task=... prioriza según la tarea) y las
+                           herramientas read_file, list_dir y write_file
+   --allow-write <patrón>  Archivos que write_file puede escribir, además
+                           de mcp_serve_write (sintaxis .gitignore);
+                           sin patrones write_file no se ofrece
+
+ SESIONES:
+   oli sessions list       Ver sesiones guardadas
+   oli sessions rm <id>    Eliminar una sesión
+   oli resume <id>         Reanudar una sesión (acepta prefijo del id)
+
+ FLAGS GLOBALES (antes de la pregunta o del comando):
+   --model <nombre>        Modelo a usar (por encima del del prompt)
+   --prompt <nombre>       Prompt a usar (ver oli prompts)
+   --url <url>             URL de Ollama (u OpenAI con backend openai)
+   --providers <lista>     Proveedores de contexto: git,filesystem usa solo
+                           esos, -index desactiva uno, none ninguno
+   --max-files <n>         Máximo de archivos en el contexto
+   --format text|json      Salida de la pregunta, agent o review; json no
+                           muestra la respuesta en streaming
+   --yes, -y               Aceptar todas las confirmaciones (los comandos
+                           denegados siguen bloqueados)
+   --allow-outside         Permitir leer y escribir fuera del directorio
+                           actual (allow_outside; también OLI_ALLOW_OUTSIDE=1)
+
+ OPCIONES DE GENERACIÓN:
+   --temperature <n>       Temperatura (0 = determinista)
+   --top-p <n>             Muestreo nucleus
+   --seed <n>              Semilla para respuestas reproducibles
+   --num-ctx <n>           Ventana de contexto en tokens (Ollama)
+   --stop <texto>          Secuencia de parada (repetible)
+
+ CONTEXTO DE GIT:
+   --diff                  Incluir siempre el diff (staged y sin preparar)
+   --base <ref>            Incluir también los cambios de <ref>...HEAD
+   Por defecto el diff se envía si la pregunta habla de cambios o revisión
+   (git_diff, diff_keywords)
+
+ CONFIGURACIÓN (cada capa sobrescribe a la anterior):
+   1. Valores predeterminados
+   2. ~/.config/oli/config.toml (o config.json)
+   3. .oli/config.toml, .oli/config.json o .oli/config del proyecto
+   4. Variables de entorno
+   5. Flags de la línea de comandos
+   oli config show         Ver los valores efectivos y de dónde viene cada uno
+   Opciones por prompt: [prompt_options.<prompt>]
+
+ PROMPTS EN MARKDOWN (~/.config/oli/prompts/*.md y .oli/prompts/*.md):
+   El nombre es el del archivo y el cuerpo, el prompt del sistema. El front
+   matter opcional fija el modelo, opciones, contexto e idioma:
+     ---
+     model: llama3.2
+     options:
+       temperature: 0.2
+     providers: [git, go-outline]
+     language: inglés
+     ---
+   Los prompts son plantillas (text/template): {{.Task}}, {{.Branch}},
+   {{.Language}}, {{.Model}}, {{range .Contexts}}{{.Provider}} {{.Content}}
+   {{end}}. {{define "user"}}...{{end}} cambia el mensaje del usuario y
+   {{template "nombre" .}} incluye prompts/snippets/nombre.md
 
  VARIABLES DE ENTORNO:
-   OLLAMA_MODEL            Modelo a usar
+   OLLAMA_MODEL            Modelo a usar (con cualquier backend)
    OLLAMA_URL              URL de Ollama
+   OLI_BACKEND             ollama | openai (llama.cpp, vLLM, LM Studio...)
+   OPENAI_BASE_URL         URL del servidor compatible con OpenAI (con /v1)
+   OPENAI_API_KEY          API key del servidor compatible con OpenAI
    OLI_PROMPT              Prompt (default, code-review, etc.)
+   OLI_ALLOW_OUTSIDE=1     Permitir leer/escribir fuera del directorio actual
 
  EJEMPLOS:
    oli que hace este proyecto
    oli read main.go
+   oli --temperature 0 --seed 1 revisa el manejo de errores
+   oli --model codellama --providers -index explica main.go
+   oli --format json -- read the docs and summarize
    OLI_PROMPT=code-review oli
+
 `)
 }
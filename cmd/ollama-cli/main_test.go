@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"ollama-cli/internal/llm"
+)
+
+func TestParseArgs(t *testing.T) {
+	temperature := 0.2
+	tests := []struct {
+		name    string
+		args    []string
+		want    globalFlags
+		rest    []string
+		literal bool
+	}{
+		{
+			name: "question only",
+			args: []string{"explica", "main.go"},
+			want: globalFlags{format: "text"},
+			rest: []string{"explica", "main.go"},
+		},
+		{
+			name: "model before question",
+			args: []string{"--model", "codellama", "explain", "x"},
+			want: globalFlags{
+				model:    "codellama",
+				format:   "text",
+				settings: []flagSetting{{"model", "codellama", "model"}},
+			},
+			rest: []string{"explain", "x"},
+		},
+		{
+			name:    "separator",
+			args:    []string{"-y", "--", "read", "main.go"},
+			want:    globalFlags{format: "text", yes: true},
+			rest:    []string{"read", "main.go"},
+			literal: true,
+		},
+		{
+			name: "flags stop at the first word",
+			args: []string{"read", "--model", "x"},
+			want: globalFlags{format: "text"},
+			rest: []string{"read", "--model", "x"},
+		},
+		{
+			name: "repeated and list flags",
+			args: []string{"--providers", "git, -index", "--providers=none", "--stop", "A", "--stop", "B", "--format", "json", "x"},
+			want: globalFlags{
+				providers: []string{"git", "-index", "none"},
+				format:    "json",
+				options:   llm.Options{Stop: []string{"A", "B"}},
+				settings:  []flagSetting{{"options.stop", []string{"A", "B"}, "stop"}},
+			},
+			rest: []string{"x"},
+		},
+		{
+			name: "config settings, sorted by flag name",
+			args: []string{"--url", "http://gpu:11434", "--temperature", "0.2", "--max-files", "5", "--diff", "--base", "main", "--allow-outside"},
+			want: globalFlags{
+				format:  "text",
+				options: llm.Options{Temperature: &temperature},
+				settings: []flagSetting{
+					{"allow_outside", true, "allow-outside"},
+					{"git_diff_base", "main", "base"},
+					{"git_diff", "always", "diff"},
+					{"max_files", 5, "max-files"},
+					{"options.temperature", 0.2, "temperature"},
+					{"url", "http://gpu:11434", "url"},
+				},
+			},
+			rest: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, rest, literal, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(f, tt.want) {
+				t.Errorf("flags = %+v\nwant %+v", f, tt.want)
+			}
+			if !reflect.DeepEqual(rest, tt.rest) {
+				t.Errorf("rest = %q, want %q", rest, tt.rest)
+			}
+			if literal != tt.literal {
+				t.Errorf("literal = %v, want %v", literal, tt.literal)
+			}
+		})
+	}
+}
+
+func TestParseArgsErrors(t *testing.T) {
+	if _, _, _, err := parseArgs([]string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("-h: err = %v, want flag.ErrHelp", err)
+	}
+	for _, args := range [][]string{
+		{"--nope", "x"},
+		{"--format", "xml", "x"},
+		{"--max-files", "muchos"},
+		{"--model"},
+	} {
+		if _, _, _, err := parseArgs(args); err == nil {
+			t.Errorf("parseArgs(%q) did not fail", args)
+		}
+	}
+}
+
+func TestLoadConfigAppliesFlags(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("OLLAMA_MODEL", "entorno")
+	t.Setenv("OLI_BACKEND", "openai")
+	t.Setenv("OLLAMA_URL", "")
+	t.Setenv("OPENAI_BASE_URL", "")
+
+	saved := flags
+	t.Cleanup(func() { flags = saved })
+	f, _, _, err := parseArgs([]string{"--model", "flag", "--url", "http://gpu/v1", "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flags = f
+
+	c, err := loadConfig(filepath.Join(t.TempDir(), "proyecto"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Model != "flag" || c.Source("model") != "--model" {
+		t.Errorf("model = %q from %q", c.Model, c.Source("model"))
+	}
+	// --url follows the effective backend
+	if c.OpenAIURL != "http://gpu/v1" || c.Source("openai_url") != "--url" || c.Source("ollama_url") != "predeterminado" {
+		t.Errorf("openai_url = %q from %q", c.OpenAIURL, c.Source("openai_url"))
+	}
+}
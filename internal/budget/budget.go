@@ -0,0 +1,244 @@
+package budget
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"ollama-cli/internal/mcp"
+)
+
+// BytesPerToken is the rough average for code and prose with the
+// tokenizers of common local models.
+const BytesPerToken = 4
+
+// EstimateTokens approximates the number of tokens in s.
+func EstimateTokens(s string) int {
+	return (len(s) + BytesPerToken - 1) / BytesPerToken
+}
+
+// Allocation describes what happened to one provider's output.
+type Allocation struct {
+	Provider string
+	Wanted   int      // Tokens the provider produced
+	Granted  int      // Tokens kept in the prompt
+	Dropped  []string // Titles of sections that were left out
+}
+
+// Trimmed reports whether any content was removed.
+func (a Allocation) Trimmed() bool {
+	return a.Granted < a.Wanted
+}
+
+// Report summarises a budget allocation.
+type Report struct {
+	Available   int // Tokens available for context
+	Used        int
+	Allocations []Allocation
+}
+
+// Trimmed reports whether any provider lost content.
+func (r Report) Trimmed() bool {
+	for _, a := range r.Allocations {
+		if a.Trimmed() {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders a one-line-per-provider summary for the user.
+func (r Report) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Contexto: %d/%d tokens", r.Used, r.Available)
+	for _, a := range r.Allocations {
+		if !a.Trimmed() {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n  %s: %d de %d tokens", a.Provider, a.Granted, a.Wanted)
+		if len(a.Dropped) > 0 {
+			fmt.Fprintf(&sb, ", omitidos: %s", strings.Join(a.Dropped, ", "))
+		}
+	}
+	return sb.String()
+}
+
+// Allocate fits results into available tokens. Providers are served in
+// descending priority, each taking what it needs from what is left; the
+// ones that do not fit are trimmed section by section. The original order
+// of results is preserved.
+func Allocate(results []mcp.ContextResult, available int, priority func(provider string) int) ([]mcp.ContextResult, Report) {
+	report := Report{Available: max(available, 0)}
+	remaining := report.Available
+
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return priority(results[order[a]].Provider) > priority(results[order[b]].Provider)
+	})
+
+	out := make([]mcp.ContextResult, len(results))
+	allocations := make([]Allocation, len(results))
+	for _, i := range order {
+		r := results[i]
+		wanted := EstimateTokens(r.Content)
+
+		content, dropped := fit(r.Content, remaining)
+		granted := EstimateTokens(content)
+		remaining -= granted
+
+		r.Content = content
+		out[i] = r
+		allocations[i] = Allocation{Provider: r.Provider, Wanted: wanted, Granted: granted, Dropped: dropped}
+		report.Used += granted
+	}
+	report.Allocations = allocations
+
+	return out, report
+}
+
+// fit trims content to at most tokens. Content is split into sections at
+// "### " headings outside code blocks (one per file in the filesystem
+// provider); whole sections are kept in order while they fit and the rest
+// are replaced by a list of their titles. Without headings, content is cut
+// at a line boundary.
+func fit(content string, tokens int) (string, []string) {
+	if EstimateTokens(content) <= tokens {
+		return content, nil
+	}
+
+	sections := splitSections(content)
+	var kept strings.Builder
+	var dropped []string
+
+	// Leave room for the note listing what was dropped
+	limit := tokens - noteReserve
+	for _, sec := range sections {
+		used := EstimateTokens(kept.String())
+		if used+EstimateTokens(sec.text) <= limit {
+			kept.WriteString(sec.text)
+			continue
+		}
+		if sec.title == "" {
+			// Text before the first heading: keep as many lines as fit
+			kept.WriteString(cutLines(sec.text, limit-used))
+			continue
+		}
+		dropped = append(dropped, sec.title)
+	}
+
+	result := strings.TrimRight(kept.String(), "\n")
+	if len(dropped) > 0 {
+		note := fmt.Sprintf("\n\n[Omitido por límite de contexto: %s]", strings.Join(dropped, ", "))
+		if EstimateTokens(result+note) <= tokens {
+			result += note
+		}
+	}
+	if EstimateTokens(result) > tokens {
+		result = cutLines(result, tokens)
+	}
+	return result, dropped
+}
+
+// noteReserve keeps space for the "[Omitido...]" note.
+const noteReserve = 64
+
+type section struct {
+	title string // Heading text without "### ", empty for leading text
+	text  string
+}
+
+func splitSections(content string) []section {
+	var sections []section
+	var title string
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() > 0 {
+			sections = append(sections, section{title: title, text: text.String()})
+		}
+		text.Reset()
+	}
+
+	// "### " inside a code block is part of a file, not a new section
+	var f fence
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if heading, ok := strings.CutPrefix(line, "### "); ok && f.open == 0 {
+			flush()
+			title = strings.TrimSpace(heading)
+		}
+		f.line(line)
+		text.WriteString(line)
+	}
+	flush()
+
+	return sections
+}
+
+// cutLines returns the longest prefix of s made of whole lines within
+// tokens. A cut inside a code fence closes it, so the rest of the prompt is
+// not read as code.
+func cutLines(s string, tokens int) string {
+	if tokens <= 0 {
+		return ""
+	}
+	limit := tokens * BytesPerToken
+	if len(s) <= limit {
+		return s
+	}
+	cut := prefixLines(s, limit)
+	if n := openFence(cut); n > 0 {
+		// Make room for the closing fence
+		cut = prefixLines(s, limit-n-1)
+		if n := openFence(cut); n > 0 {
+			cut += strings.Repeat("`", n) + "\n"
+		}
+	}
+	return cut
+}
+
+// prefixLines returns the longest prefix of s made of whole lines within
+// limit bytes.
+func prefixLines(s string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	cut := strings.LastIndexByte(s[:min(limit, len(s))], '\n')
+	if cut < 0 {
+		return ""
+	}
+	return s[:cut+1]
+}
+
+// fence follows Markdown code fences: one opened with n backticks is only
+// closed by a line of at least n backticks, so a fence can hold shorter
+// ones.
+type fence struct {
+	open int // Backticks of the open fence; 0 outside code
+}
+
+func (f *fence) line(line string) {
+	t := strings.TrimSpace(line)
+	n := len(t) - len(strings.TrimLeft(t, "`"))
+	if n < 3 {
+		return
+	}
+	switch {
+	case f.open == 0:
+		f.open = n
+	case n >= f.open && n == len(t):
+		f.open = 0
+	}
+}
+
+// openFence returns the backticks of the fence left open at the end of s,
+// or 0.
+func openFence(s string) int {
+	var f fence
+	for _, line := range strings.SplitAfter(s, "\n") {
+		f.line(line)
+	}
+	return f.open
+}
@@ -0,0 +1,82 @@
+package budget
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// file renders one section the way the filesystem provider does.
+func file(name, fence, content string) string {
+	return "### " + name + "\n" + fence + "\n" + content + "\n" + fence
+}
+
+func titles(sections []section) []string {
+	var out []string
+	for _, s := range sections {
+		out = append(out, s.title)
+	}
+	return out
+}
+
+func TestSplitSectionsIgnoresHeadingsInCode(t *testing.T) {
+	readme := "# Proyecto\n\n### Instalación\n\n```bash\nmake\n```\n\n### Uso"
+	content := "## Contenido\n\n" +
+		file("README.md", "````", readme) + "\n\n" +
+		file("main.go", "```", "package main")
+
+	got := titles(splitSections(content))
+	want := []string{"", "README.md", "main.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sections = %q, want %q", got, want)
+	}
+}
+
+func TestFitClosesFenceWhenCutting(t *testing.T) {
+	body := strings.Repeat("line of code\n", 200)
+	content := "```\n" + body + "```"
+
+	got, _ := fit(content, 100)
+	if EstimateTokens(got) > 100 {
+		t.Errorf("fit used %d tokens, limit 100", EstimateTokens(got))
+	}
+	if openFence(got) != 0 {
+		t.Errorf("fit left a fence open:\n%s", got[max(0, len(got)-40):])
+	}
+	if !strings.HasSuffix(got, "\n```") {
+		t.Errorf("fit did not close the fence: %q", got[max(0, len(got)-20):])
+	}
+}
+
+func TestFitDropsWholeFiles(t *testing.T) {
+	big := strings.Repeat("x\n", 400)
+	content := file("a.md", "````", "### not a section\n```\ncode\n```") + "\n\n" +
+		file("b.go", "```", big)
+
+	got, dropped := fit(content, 120)
+	if !reflect.DeepEqual(dropped, []string{"b.go"}) {
+		t.Errorf("dropped = %q, want [b.go]", dropped)
+	}
+	if !strings.Contains(got, "### not a section") || openFence(got) != 0 {
+		t.Errorf("a.md should be kept whole:\n%s", got)
+	}
+}
+
+func TestOpenFence(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"no code", 0},
+		{"```go\ncode\n```\n", 0},
+		{"```\ncode\n", 3},
+		{"````\n```\ninner\n```\n", 4},
+		{"````\n```\ninner\n```\n````\n", 0},
+		{"```\n```` not a closing line\n", 3},
+	}
+	for _, tt := range tests {
+		if got := openFence(tt.text); got != tt.want {
+			t.Errorf("openFence(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"ollama-cli/internal/llm"
+	"ollama-cli/internal/tools"
+)
+
+// agentTool une la definición que ve el modelo con la función que la ejecuta
+type agentTool struct {
+	def llm.Tool
+	run func(ctx context.Context, args map[string]any) (string, error)
+}
+
+// agentTools devuelve las herramientas disponibles para el modo agente
+func (a *App) agentTools() []agentTool {
+	return []agentTool{
+		{
+			def: llm.Tool{
+				Name:        "read_file",
+				Description: "Lee el contenido completo de un archivo del proyecto.",
+				Parameters: objectSchema(map[string]any{
+					"path": stringSchema("Ruta del archivo, relativa al directorio actual"),
+				}, "path"),
+			},
+			run: func(ctx context.Context, args map[string]any) (string, error) {
+				path, err := stringArg(args, "path")
+				if err != nil {
+					return "", err
+				}
+				return tools.ReadFile(path)
+			},
+		},
+		{
+			def: llm.Tool{
+				Name:        "list_dir",
+				Description: "Lista los archivos y carpetas de un directorio. Las carpetas terminan en '/'.",
+				Parameters: objectSchema(map[string]any{
+					"path": stringSchema("Directorio a listar; '.' para el directorio actual"),
+				}),
+			},
+			run: func(ctx context.Context, args map[string]any) (string, error) {
+				path, _ := stringArg(args, "path")
+				files, err := tools.ListDir(path)
+				if err != nil {
+					return "", err
+				}
+				return strings.Join(files, "\n"), nil
+			},
+		},
+		{
+			def: llm.Tool{
+				Name:        "write_file",
+				Description: "Crea o reemplaza un archivo con el contenido indicado. El usuario debe confirmar.",
+				Parameters: objectSchema(map[string]any{
+					"path":    stringSchema("Ruta del archivo, relativa al directorio actual"),
+					"content": stringSchema("Contenido completo del archivo"),
+				}, "path", "content"),
+			},
+			run: func(ctx context.Context, args map[string]any) (string, error) {
+				path, err := stringArg(args, "path")
+				if err != nil {
+					return "", err
+				}
+				content, err := stringArg(args, "content")
+				if err != nil {
+					return "", err
+				}
+				if err := tools.WriteFile(path, content); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Archivo guardado: %s (%d bytes)", path, len(content)), nil
+			},
+		},
+		{
+			def: llm.Tool{
+				Name: "run_command",
+				Description: "Ejecuta un comando en el directorio del proyecto y devuelve stdout, stderr y exit code. " +
+					"Se ejecuta sin shell: no uses tuberías, redirecciones ni '&&'. Algunos comandos piden confirmación o están bloqueados.",
+				Parameters: objectSchema(map[string]any{
+					"command": stringSchema("Comando completo, por ejemplo 'go test ./...'"),
+				}, "command"),
+			},
+			run: func(ctx context.Context, args map[string]any) (string, error) {
+				command, err := stringArg(args, "command")
+				if err != nil {
+					return "", err
+				}
+				result, err := tools.RunCommand(ctx, command, a.CommandOptions())
+				if err != nil {
+					return "", err
+				}
+				return result.String(), nil
+			},
+		},
+	}
+}
+
+// RunAgent ejecuta una tarea en modo agente: el modelo pide herramientas,
+// oli las ejecuta y le devuelve el resultado hasta obtener una respuesta
+// final o alcanzar Config.AgentMaxSteps.
+func (a *App) RunAgent(ctx context.Context, task string) error {
+	_, err := a.agent(ctx, task, func(chunk string) {
+		fmt.Print(chunk)
+	})
+	fmt.Println()
+	return err
+}
+
+// AnswerAgent es RunAgent sin mostrar la respuesta: la devuelve para quien
+// la procesa (--format json). El progreso sigue yendo a stderr.
+func (a *App) AnswerAgent(ctx context.Context, task string) (string, error) {
+	return a.agent(ctx, task, func(string) {})
+}
+
+// agent ejecuta el bucle del modo agente; onChunk recibe el texto del
+// modelo en streaming
+func (a *App) agent(ctx context.Context, task string, onChunk func(string)) (string, error) {
+	workDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("get working directory: %w", err)
+	}
+
+	fmt.Fprintln(os.Stderr, "Leyendo proyecto...")
+	contexts := a.gatherWithinBudget(ctx, workDir, task, a.cfg.AgentPrompt)
+	a.contextSummary = summarizeContext(contexts)
+
+	system, user := a.build(ctx, contexts, task)
+	system += "\n\n" + a.cfg.AgentPrompt
+
+	available := append(a.agentTools(), a.mcpTools...)
+	byName := make(map[string]agentTool, len(available))
+	defs := make([]llm.Tool, 0, len(available))
+	for _, t := range available {
+		byName[t.def.Name] = t
+		defs = append(defs, t.def)
+	}
+
+	messages := make([]llm.Message, 0, len(a.history)+2)
+	messages = append(messages, llm.Message{Role: llm.RoleSystem, Content: system})
+	messages = append(messages, a.history...)
+	messages = append(messages, llm.Message{Role: llm.RoleUser, Content: user})
+
+	fmt.Fprintln(os.Stderr, "---")
+	for step := 1; step <= a.cfg.AgentMaxSteps; step++ {
+		reply, err := a.client.Chat(ctx, llm.ChatRequest{
+			Model:    a.model,
+			Messages: messages,
+			Tools:    defs,
+			Options:  &a.options,
+		}, onChunk)
+		if err != nil {
+			return "", err
+		}
+		messages = append(messages, reply)
+
+		// Sin llamadas a herramientas: es la respuesta final
+		if len(reply.ToolCalls) == 0 {
+			a.history = append(a.history,
+				llm.Message{Role: llm.RoleUser, Content: task},
+				llm.Message{Role: llm.RoleAssistant, Content: reply.Content})
+			return reply.Content, nil
+		}
+
+		for _, call := range reply.ToolCalls {
+			fmt.Fprintf(os.Stderr, "\n [%d/%d] %s\n", step, a.cfg.AgentMaxSteps, describeCall(call))
+
+			result := a.runTool(ctx, byName, call)
+			messages = append(messages, llm.Message{
+				Role:       llm.RoleTool,
+				Content:    result,
+				ToolName:   call.Name,
+				ToolCallID: call.ID,
+			})
+		}
+	}
+
+	return "", fmt.Errorf("límite de %d pasos alcanzado sin respuesta final", a.cfg.AgentMaxSteps)
+}
+
+// runTool ejecuta una llamada y devuelve el texto que verá el modelo.
+// Los errores también se devuelven como texto para que el modelo pueda corregirse.
+func (a *App) runTool(ctx context.Context, byName map[string]agentTool, call llm.ToolCall) string {
+	t, ok := byName[call.Name]
+	if !ok {
+		return fmt.Sprintf("Error: herramienta desconocida %q", call.Name)
+	}
+
+	result, err := t.run(ctx, call.Arguments)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "   error: %v\n", err)
+		return fmt.Sprintf("Error: %v", err)
+	}
+
+	if len(result) > a.cfg.MaxToolOutput {
+		result = tools.CutUTF8(result, a.cfg.MaxToolOutput) + fmt.Sprintf("\n[... recortado, %d bytes en total]", len(result))
+	}
+	return result
+}
+
+// describeCall muestra una llamada de forma compacta, sin volcar contenidos largos
+func describeCall(call llm.ToolCall) string {
+	keys := make([]string, 0, len(call.Arguments))
+	for k := range call.Arguments {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var args []string
+	for _, k := range keys {
+		s := fmt.Sprint(call.Arguments[k])
+		if len(s) > 60 {
+			s = fmt.Sprintf("<%d bytes>", len(s))
+		}
+		args = append(args, fmt.Sprintf("%s=%s", k, s))
+	}
+	return fmt.Sprintf("%s(%s)", call.Name, strings.Join(args, ", "))
+}
+
+func objectSchema(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringSchema(description string) map[string]any {
+	return map[string]any{"type": "string", "description": description}
+}
+
+// stringArg extrae un argumento de texto. Algunos modelos envían valores
+// no textuales, que se convierten a su representación JSON.
+func stringArg(args map[string]any, name string) (string, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return "", fmt.Errorf("falta el argumento %q", name)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("argumento %q inválido: %w", name, err)
+	}
+	return string(data), nil
+}
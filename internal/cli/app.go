@@ -6,86 +6,262 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
+	"ollama-cli/internal/budget"
 	"ollama-cli/internal/config"
 	"ollama-cli/internal/llm"
 	"ollama-cli/internal/mcp"
+	"ollama-cli/internal/patch"
 	"ollama-cli/internal/prompt"
 	"ollama-cli/internal/tools"
 )
 
 type App struct {
+	cfg       *config.Config
 	model     string
 	client    llm.Client
 	providers []mcp.ContextProvider
 	builder   *prompt.Builder
+	commands  tools.CommandPolicy
+	options   llm.Options
+
+	// prompt es el prompt en uso, enabled los proveedores que activa
+	// (vacío = todos) y language el idioma de las respuestas; overrides
+	// son las opciones fijadas con SetOptions
+	prompt    string
+	enabled   []string
+	language  string
+	overrides llm.Options
+
+	// only y disabled son los proveedores fijados con SetProviders, que
+	// tienen prioridad sobre los del prompt (only != nil: solo esos)
+	only     []string
+	disabled []string
+
+	// history guarda la conversación (preguntas y respuestas) para que
+	// las preguntas de seguimiento tengan memoria de los turnos anteriores.
+	history []llm.Message
+
+	// contextSummary describe el último contexto recopilado (proveedores y tamaño)
+	contextSummary string
+
+	// windows guarda la ventana de contexto consultada para cada modelo
+	windows map[string]int
+
+	// Servidores MCP lanzados (ver connectMCP) y sus herramientas
+	mcpStarted bool
+	mcpClients []*mcp.Client
+	mcpTools   []agentTool
 }
 
-func New() *App {
-	model := config.Model
-	if env := os.Getenv("OLLAMA_MODEL"); env != "" {
-		model = env
+// New crea la app con la configuración efectiva (ver config.Load)
+func New(cfg *config.Config) *App {
+	client := newClient(cfg)
+	git := mcp.NewGitProvider()
+	git.SetDiff(mcp.DiffMode(cfg.GitDiff), cfg.GitDiffBase)
+	git.SetDiffKeywords(cfg.DiffKeywords)
+	files := mcp.NewFilesystemProvider(cfg.MaxFiles, cfg.MaxDepth)
+	files.SetOnRead(tools.RememberRead)
+	providers := []mcp.ContextProvider{
+		files,
+		git,
+		mcp.NewGoOutlineProvider(cfg.OutlineDepth),
+	}
+	if embedder, ok := client.(llm.Embedder); ok {
+		providers = append(providers, mcp.NewIndexProvider(embedder, cfg.IndexTopK))
 	}
 
-	ollamaURL := config.OllamaURL
-	if env := os.Getenv("OLLAMA_URL"); env != "" {
-		ollamaURL = env
+	builder, err := prompt.NewTemplateBuilder(cfg.SystemPrompt, cfg.Snippets())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Aviso: plantilla del prompt del sistema: %v\n", err)
+		builder = prompt.NewBuilder(cfg.SystemPrompt)
 	}
 
 	return &App{
-		model:  model,
-		client: llm.NewOllamaClient(ollamaURL),
-		providers: []mcp.ContextProvider{
-			mcp.NewFilesystemProvider(config.MaxFiles, config.MaxDepth),
-			mcp.NewGitProvider(),
-		},
-		builder: prompt.NewBuilder(config.SystemPrompt),
+		cfg:       cfg,
+		model:     cfg.Model,
+		client:    client,
+		providers: providers,
+		builder:   builder,
+		prompt:    "default",
+		language:  cfg.Language,
+		commands:  tools.NewCommandPolicy(cfg.CommandAllow, cfg.CommandConfirm, cfg.CommandDeny),
+		options:   cfg.Options,
 	}
 }
 
-func NewWithPrompt(promptName string) *App {
-	app := New()
-	if p, ok := config.Prompts[promptName]; ok {
-		app.builder = prompt.NewBuilder(p)
+// newClient crea el cliente del backend configurado (Config.Backend)
+func newClient(cfg *config.Config) llm.Client {
+	switch cfg.Backend {
+	case "openai":
+		return llm.NewOpenAIClient(cfg.OpenAIURL, os.Getenv("OPENAI_API_KEY"))
+	case "ollama":
+	default:
+		fmt.Fprintf(os.Stderr, "Aviso: backend desconocido %q, usando ollama\n", cfg.Backend)
 	}
-	return app
+	return llm.NewOllamaClient(cfg.OllamaURL)
 }
 
+// NewWithPrompt crea la app con el prompt promptName (ver UsePrompt)
+func NewWithPrompt(cfg *config.Config, promptName string) (*App, error) {
+	app := New(cfg)
+	if err := app.UsePrompt(promptName); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// UsePrompt cambia al prompt promptName: su texto pasa a ser el prompt del
+// sistema y se aplican su modelo, opciones, proveedores e idioma. Las
+// opciones de SetOptions siguen teniendo prioridad. Si el prompt fija
+// language y no usa {{.Language}}, se le agrega "Responde siempre en
+// {{.Language}}." para que el idioma tenga efecto.
+func (a *App) UsePrompt(promptName string) error {
+	p, ok := a.cfg.Profile(promptName)
+	if !ok {
+		return fmt.Errorf("prompt desconocido %q (ver 'prompts')", promptName)
+	}
+
+	system := p.System
+	if p.Language != "" && !strings.Contains(system, ".Language") {
+		system += "\n\nResponde siempre en {{.Language}}."
+	}
+	builder, err := prompt.NewTemplateBuilder(system, a.cfg.Snippets())
+	if err != nil {
+		return fmt.Errorf("prompt %s (%s): %w", p.Name, p.Source, err)
+	}
+	a.builder = builder
+	a.language = a.cfg.Language
+	if p.Language != "" {
+		a.language = p.Language
+	}
+	a.model = a.cfg.Model
+	if p.Model != "" {
+		a.model = p.Model
+	}
+	a.options = a.cfg.Options.Merge(p.Options).Merge(a.overrides)
+	a.enabled = p.Providers
+	a.prompt = p.Name
+	return nil
+}
+
+// build arma los mensajes del sistema y del usuario con el prompt en uso
+func (a *App) build(ctx context.Context, contexts []mcp.ContextResult, task string) (system, user string) {
+	vars := prompt.Vars{Language: a.language, Model: a.model, Prompt: a.prompt}
+	if workDir, err := os.Getwd(); err == nil {
+		vars.Branch = a.gitProvider().CurrentBranch(ctx, workDir)
+	}
+	a.builder.SetVars(vars)
+	return a.builder.Build(contexts, task)
+}
+
+// Prompt devuelve el nombre del prompt en uso
+func (a *App) Prompt() string {
+	return a.prompt
+}
+
+// SetOptions sobrescribe las opciones de generación que vengan definidas
+// (por ejemplo desde flags de la línea de comandos)
+func (a *App) SetOptions(override llm.Options) {
+	a.overrides = a.overrides.Merge(override)
+	a.options = a.options.Merge(override)
+}
+
+// Run responde una pregunta mostrando la respuesta a medida que llega y
+// ofrece aplicar las ediciones y guardar los bloques de código
 func (a *App) Run(ctx context.Context, task string) error {
+	reply, err := a.ask(ctx, task, func(chunk string) {
+		fmt.Print(chunk)
+	})
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+
+	// Detectar ediciones y bloques de código y ofrecer aplicarlos
+	a.offerToApplyEdits(reply.Content)
+	a.offerToSaveCodeBlocks(reply.Content)
+
+	return nil
+}
+
+// Answer responde una pregunta sin mostrar nada por la salida estándar ni
+// ofrecer ediciones, para quien procesa la respuesta (--format json)
+func (a *App) Answer(ctx context.Context, task string) (string, error) {
+	reply, err := a.ask(ctx, task, func(string) {})
+	if err != nil {
+		return "", err
+	}
+	return reply.Content, nil
+}
+
+// ask recopila el contexto, envía la pregunta con el historial y la
+// agrega a la conversación; onChunk recibe la respuesta en streaming
+func (a *App) ask(ctx context.Context, task string, onChunk func(string)) (llm.Message, error) {
 	workDir, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("get working directory: %w", err)
+		return llm.Message{}, fmt.Errorf("get working directory: %w", err)
 	}
 
 	// 1. Recopilar contexto automáticamente (lee archivos del proyecto)
 	fmt.Fprintln(os.Stderr, "Leyendo proyecto...")
-	contexts := a.gatherContext(ctx, workDir)
+	contexts := a.gatherWithinBudget(ctx, workDir, task, "")
+	a.contextSummary = summarizeContext(contexts)
 
 	// 2. Construir prompt
-	system, user := a.builder.Build(contexts, task)
+	system, user := a.build(ctx, contexts, task)
 
-	// 3. Stream response y capturar para detectar código
+	// 3. Armar la conversación: el turno actual lleva el contexto fresco,
+	// los anteriores solo la pregunta original para no repetirlo.
+	messages := make([]llm.Message, 0, len(a.history)+2)
+	messages = append(messages, llm.Message{Role: llm.RoleSystem, Content: system})
+	messages = append(messages, a.history...)
+	messages = append(messages, llm.Message{Role: llm.RoleUser, Content: user})
+
+	// 4. Stream response
 	fmt.Fprintln(os.Stderr, "---")
-	var fullResponse strings.Builder
+	reply, err := a.client.Chat(ctx, llm.ChatRequest{
+		Model:    a.model,
+		Messages: messages,
+		Options:  &a.options,
+	}, onChunk)
+	if err != nil {
+		return llm.Message{}, err
+	}
 
-	err = a.client.Generate(ctx, llm.GenerateRequest{
-		Model:  a.model,
-		System: system,
-		Prompt: user,
-	}, func(chunk string) {
-		fmt.Print(chunk)
-		fullResponse.WriteString(chunk)
-	})
-	fmt.Println()
+	a.history = append(a.history, llm.Message{Role: llm.RoleUser, Content: task}, reply)
+	return reply, nil
+}
 
-	if err != nil {
-		return err
+// SetDiff cambia cuándo se envía el diff de git (ver Config.GitDiff) y la
+// ref base opcional
+func (a *App) SetDiff(mode mcp.DiffMode, base string) {
+	for _, p := range a.providers {
+		if git, ok := p.(*mcp.GitProvider); ok {
+			git.SetDiff(mode, base)
+		}
 	}
+}
 
-	// 4. Detectar bloques de código y ofrecer guardar
-	a.offerToSaveCodeBlocks(fullResponse.String())
+// Reset olvida la conversación actual
+func (a *App) Reset() {
+	a.history = nil
+}
 
-	return nil
+// History devuelve la conversación acumulada (sin el prompt del sistema)
+func (a *App) History() []llm.Message {
+	return a.history
+}
+
+// SetHistory restaura una conversación previa, por ejemplo al reanudar una sesión
+func (a *App) SetHistory(history []llm.Message) {
+	a.history = history
+}
+
+// ContextSummary describe el último contexto enviado al modelo
+func (a *App) ContextSummary() string {
+	return a.contextSummary
 }
 
 // offerToSaveCodeBlocks detecta bloques de código en la respuesta y ofrece guardarlos
@@ -98,7 +274,8 @@ func (a *App) offerToSaveCodeBlocks(response string) {
 		regexp.MustCompile("(?i)(?:archivo|file)[:\\s]+([\\w\\-./]+\\.[\\w]+)\\s*\\n```\\w*\\n([\\s\\S]*?)```"),
 	}
 
-	savedFiles := make(map[string]bool)
+	// Cada archivo se ofrece una sola vez aunque coincida con varios patrones
+	offered := make(map[string]bool)
 
 	for _, pattern := range patterns {
 		matches := pattern.FindAllStringSubmatch(response, -1)
@@ -107,31 +284,121 @@ func (a *App) offerToSaveCodeBlocks(response string) {
 				filename := match[1]
 				content := strings.TrimSpace(match[2])
 
-				if savedFiles[filename] {
+				// Los diffs y bloques SEARCH/REPLACE se aplican en offerToApplyEdits
+				if offered[filename] || patch.IsEdit(content) {
 					continue
 				}
+				offered[filename] = true
 
-				fmt.Printf("\n Código detectado para: %s\n", filename)
-				if tools.AskConfirmation(fmt.Sprintf("¿Guardar archivo '%s'?", filename)) {
-					if err := tools.WriteFileDirectly(filename, content); err != nil {
-						fmt.Printf(" Error guardando: %v\n", err)
-					} else {
-						fmt.Printf(" Guardado: %s\n", filename)
-						savedFiles[filename] = true
-					}
-				}
+				a.reviewAndSave(filename, content)
 			}
 		}
 	}
 }
 
+// CommandOptions devuelve la política y límites para ejecutar comandos
+func (a *App) CommandOptions() tools.CommandOptions {
+	return tools.CommandOptions{
+		Policy:    a.commands,
+		Timeout:   time.Duration(a.cfg.CommandTimeout) * time.Second,
+		MaxOutput: a.cfg.MaxToolOutput / 2,
+	}
+}
+
 func (a *App) GetModel() string {
 	return a.model
 }
 
+func (a *App) SetModel(model string) {
+	a.model = model
+}
+
+// gatherWithinBudget recopila el contexto y lo ajusta a la ventana del
+// modelo: descuenta el prompt del sistema (más extraSystem), el historial,
+// la tarea y la reserva para la respuesta, y reparte el resto entre los
+// proveedores según Config.ProviderPriority.
+func (a *App) gatherWithinBudget(ctx context.Context, workDir, task, extraSystem string) []mcp.ContextResult {
+	a.connectMCP(ctx)
+	window, available := a.contextBudget(ctx, task, extraSystem)
+
+	// Evitar que los proveedores lean más de lo que cabe
+	for _, p := range a.providers {
+		if limited, ok := p.(mcp.SizeLimited); ok {
+			limited.SetMaxSize(max(available, 0) * budget.BytesPerToken)
+		}
+	}
+
+	results := a.gatherContext(mcp.WithTask(ctx, task), workDir)
+	results, report := budget.Allocate(results, available, a.providerPriority)
+
+	if available <= 0 {
+		fmt.Fprintf(os.Stderr, "Aviso: la conversación ya ocupa la ventana de %d tokens; usa 'nueva' para empezar de cero\n", window)
+	} else if report.Trimmed() {
+		fmt.Fprintln(os.Stderr, report.String())
+	}
+	return results
+}
+
+// providerPriority busca la prioridad de un proveedor en
+// Config.ProviderPriority; "mcp:docs" usa la de "mcp" si no tiene propia.
+func (a *App) providerPriority(provider string) int {
+	if p, ok := a.cfg.ProviderPriority[provider]; ok {
+		return p
+	}
+	if prefix, _, ok := strings.Cut(provider, ":"); ok {
+		return a.cfg.ProviderPriority[prefix]
+	}
+	return 0
+}
+
+// contextBudget devuelve la ventana del modelo y los tokens que quedan para
+// contexto tras el prompt del sistema (más extraSystem), el historial, la
+// tarea y la reserva para la respuesta. available puede ser negativo.
+func (a *App) contextBudget(ctx context.Context, task, extraSystem string) (window, available int) {
+	window = a.contextWindow(ctx)
+
+	system, user := a.build(ctx, nil, task)
+	fixed := budget.EstimateTokens(system + extraSystem + user)
+	for _, m := range a.history {
+		fixed += budget.EstimateTokens(m.Content)
+	}
+	return window, window - window*a.cfg.ResponseReserve/100 - fixed
+}
+
+// contextWindow devuelve la ventana de contexto efectiva en tokens:
+// Options.NumCtx si está fijado, limitado por lo que admite el modelo
+// cuando el backend lo informa; si no, Config.ContextWindow.
+func (a *App) contextWindow(ctx context.Context) int {
+	modelMax, ok := a.windows[a.model]
+	if !ok {
+		if info, isInfo := a.client.(llm.ModelInfo); isInfo {
+			if n, err := info.ContextLength(ctx, a.model); err == nil {
+				modelMax = n
+			}
+		}
+		if a.windows == nil {
+			a.windows = make(map[string]int)
+		}
+		a.windows[a.model] = modelMax
+	}
+
+	switch {
+	case a.options.NumCtx > 0 && modelMax > 0:
+		return min(a.options.NumCtx, modelMax)
+	case a.options.NumCtx > 0:
+		return a.options.NumCtx
+	case modelMax > 0:
+		return modelMax
+	}
+	return a.cfg.ContextWindow
+}
+
 func (a *App) gatherContext(ctx context.Context, workDir string) []mcp.ContextResult {
 	var results []mcp.ContextResult
 	for _, p := range a.providers {
+		if !a.providerEnabled(p.Name()) {
+			continue
+		}
 		result, err := p.Gather(ctx, workDir)
 		if err != nil {
 			result = mcp.ContextResult{
@@ -143,3 +410,86 @@ func (a *App) gatherContext(ctx context.Context, workDir string) []mcp.ContextRe
 	}
 	return results
 }
+
+// SetProviders ajusta los proveedores de contexto por encima de los del
+// prompt: "git" y "filesystem" dejan solo esos, "-index" desactiva uno y
+// "none" los desactiva todos
+func (a *App) SetProviders(names []string) error {
+	var only, disabled []string
+	for _, name := range names {
+		off := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		if name == "none" && !off {
+			only = []string{}
+			continue
+		}
+		if !a.knownProvider(name) {
+			return fmt.Errorf("proveedor desconocido %q (disponibles: %s)", name, strings.Join(a.providerNames(), ", "))
+		}
+		if off {
+			disabled = append(disabled, name)
+		} else {
+			only = append(only, name)
+		}
+	}
+	a.only, a.disabled = only, disabled
+	return nil
+}
+
+// knownProvider indica si name es un proveedor de contexto; los de MCP
+// ("mcp" o "mcp:servidor") se lanzan más tarde y se aceptan siempre
+func (a *App) knownProvider(name string) bool {
+	if name == "mcp" || strings.HasPrefix(name, "mcp:") {
+		return true
+	}
+	for _, p := range a.providers {
+		if p.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *App) providerNames() []string {
+	names := make([]string, 0, len(a.providers)+1)
+	for _, p := range a.providers {
+		names = append(names, p.Name())
+	}
+	return append(names, "mcp")
+}
+
+// providerEnabled indica si el proveedor está activo: primero se aplica
+// SetProviders y después el prompt en uso. "mcp" activa todos los
+// servidores MCP ("mcp:docs" solo uno)
+func (a *App) providerEnabled(name string) bool {
+	if matchProvider(a.disabled, name) {
+		return false
+	}
+	if a.only != nil {
+		return matchProvider(a.only, name)
+	}
+	return len(a.enabled) == 0 || matchProvider(a.enabled, name)
+}
+
+func matchProvider(list []string, name string) bool {
+	for _, e := range list {
+		if e == name || strings.HasPrefix(name, e+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// summarizeContext resume qué proveedores aportaron contexto y cuánto
+func summarizeContext(results []mcp.ContextResult) string {
+	var parts []string
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			parts = append(parts, fmt.Sprintf("%s: error (%s)", r.Provider, r.Error))
+		case r.Content != "":
+			parts = append(parts, fmt.Sprintf("%s: %.1fKB", r.Provider, float64(len(r.Content))/1024))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"ollama-cli/internal/budget"
+	"ollama-cli/internal/llm"
+	"ollama-cli/internal/mcp"
+	"ollama-cli/internal/tools"
+)
+
+// ErrNothingStaged se devuelve al pedir un commit sin cambios preparados
+var ErrNothingStaged = errors.New("no hay cambios preparados para commit (usa git add)")
+
+// conventionalSubject reconoce "tipo(ámbito)!: resumen"
+var conventionalSubject = regexp.MustCompile(`^(feat|fix|docs|style|refactor|perf|test|build|ci|chore|revert)(\([^()]+\))?!?: \S`)
+
+// Commit redacta un mensaje para los cambios preparados, deja aceptarlo,
+// editarlo o pedir otro, y hace el commit. hint se agrega a la petición
+// (por ejemplo el motivo del cambio).
+func (a *App) Commit(ctx context.Context, hint string) error {
+	workDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	git := a.gitProvider()
+	diff, err := git.StagedDiff(ctx, workDir)
+	if err != nil {
+		return fmt.Errorf("git diff: %w", err)
+	}
+	if strings.TrimSpace(diff) == "" {
+		return ErrNothingStaged
+	}
+
+	task := fmt.Sprintf("Escribe el mensaje de commit para estos cambios. El asunto no debe "+
+		"pasar de %d caracteres y las líneas del cuerpo, de %d columnas.",
+		a.cfg.CommitSubjectMax, a.cfg.CommitBodyWidth)
+	if hint != "" {
+		task += "\nContexto del autor: " + hint
+	}
+	messages := []llm.Message{
+		{Role: llm.RoleSystem, Content: a.cfg.CommitPrompt},
+		{Role: llm.RoleUser, Content: a.stagedContext(ctx, diff, task) + "\n\n## Task\n" + task},
+	}
+
+	var message string
+	regenerate := true
+	for {
+		if regenerate {
+			fmt.Fprintln(os.Stderr, "Redactando mensaje...")
+			message, messages, err = a.draftCommit(ctx, messages)
+			if err != nil {
+				return err
+			}
+			regenerate = false
+		}
+
+		fmt.Println("\n────────────────────────────────")
+		fmt.Println(message)
+		fmt.Println("────────────────────────────────")
+		for _, w := range checkCommitMessage(message, a.cfg.CommitSubjectMax) {
+			fmt.Printf(" Aviso: %s\n", w)
+		}
+
+		choice := tools.AskChoice("¿Commit? [s]í / [e]ditar / [r]egenerar / [n]o", "s", "e", "r", "n")
+		switch choice {
+		case "s":
+			out, err := git.Commit(ctx, workDir, message)
+			if err != nil {
+				return fmt.Errorf("git commit: %w", err)
+			}
+			fmt.Print(out)
+			return nil
+		case "e":
+			edited, err := tools.EditInEditor(message, ".txt")
+			if err != nil {
+				fmt.Printf(" Error: %v\n", err)
+				continue
+			}
+			// Como git commit: un mensaje vacío cancela
+			if message = formatCommitMessage(edited, a.cfg.CommitBodyWidth); message == "" {
+				fmt.Println(" Commit cancelado: mensaje vacío")
+				return nil
+			}
+		case "r":
+			messages = append(messages, llm.Message{Role: llm.RoleUser, Content: "Propón otro mensaje distinto."})
+			regenerate = true
+		default:
+			fmt.Println(" Commit cancelado")
+			return nil
+		}
+	}
+}
+
+// commitRetries es cuántas veces se pide acortar un asunto demasiado largo
+const commitRetries = 2
+
+// draftCommit pide un mensaje al modelo y, si el asunto supera
+// CommitSubjectMax, le pide acortarlo hasta commitRetries veces.
+// Devuelve el mensaje y la conversación con las respuestas agregadas.
+func (a *App) draftCommit(ctx context.Context, messages []llm.Message) (string, []llm.Message, error) {
+	var message string
+	for attempt := 0; ; attempt++ {
+		reply, err := a.client.Chat(ctx, llm.ChatRequest{
+			Model:    a.model,
+			Messages: messages,
+			Options:  &a.options,
+		}, func(string) {})
+		if err != nil {
+			return "", messages, err
+		}
+		message = formatCommitMessage(reply.Content, a.cfg.CommitBodyWidth)
+		messages = append(messages, reply)
+
+		subject, _, _ := strings.Cut(message, "\n")
+		n := len([]rune(subject))
+		if n <= a.cfg.CommitSubjectMax || attempt == commitRetries {
+			return message, messages, nil
+		}
+		messages = append(messages, llm.Message{Role: llm.RoleUser, Content: fmt.Sprintf(
+			"El asunto tiene %d caracteres; acórtalo a %d como máximo y responde con el mensaje completo.",
+			n, a.cfg.CommitSubjectMax)})
+	}
+}
+
+// stagedContext arma el diff preparado, un archivo por sección, recortado
+// a lo que cabe en la ventana del modelo
+func (a *App) stagedContext(ctx context.Context, diff, task string) string {
+	var sections []string
+	for _, d := range mcp.SplitDiff(diff) {
+		fence := mcp.CodeFence(d.Text)
+		sections = append(sections, fmt.Sprintf("### %s\n%sdiff\n%s%s", d.Path, fence, d.Text, fence))
+	}
+	contexts := []mcp.ContextResult{{Provider: "staged", Content: strings.Join(sections, "\n\n")}}
+
+	window := a.contextWindow(ctx)
+	available := window - window*a.cfg.ResponseReserve/100 - budget.EstimateTokens(a.cfg.CommitPrompt+task)
+	contexts, report := budget.Allocate(contexts, available, func(string) int { return 0 })
+	if report.Trimmed() {
+		fmt.Fprintln(os.Stderr, report.String())
+	}
+	return "## Context: staged\n" + contexts[0].Content
+}
+
+// formatCommitMessage limpia la respuesta del modelo (bloques de código,
+// comillas, espacios) y ajusta el cuerpo a width columnas.
+func formatCommitMessage(raw string, width int) string {
+	text := strings.TrimSpace(raw)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text[strings.IndexByte(text+"\n", '\n'):], "\n")
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+	}
+	text = unquote(text)
+
+	lines := strings.Split(text, "\n")
+	subject := strings.TrimSpace(lines[0])
+	body := strings.TrimSpace(strings.Join(lines[1:], "\n"))
+	if body == "" {
+		return subject
+	}
+
+	var wrapped []string
+	for _, line := range strings.Split(body, "\n") {
+		wrapped = append(wrapped, wrapLine(strings.TrimRight(line, " \t"), width)...)
+	}
+	return subject + "\n\n" + strings.Join(wrapped, "\n")
+}
+
+// unquote quita las comillas o acentos graves que envuelven todo el
+// mensaje; "`Config` cambia" no está envuelto y queda igual
+func unquote(text string) string {
+	if len(text) < 2 || !strings.ContainsRune("\"'`", rune(text[0])) || text[len(text)-1] != text[0] {
+		return text
+	}
+	inner := text[1 : len(text)-1]
+	if strings.IndexByte(inner, text[0]) >= 0 {
+		return text
+	}
+	return strings.TrimSpace(inner)
+}
+
+// wrapLine parte una línea en palabras hasta width columnas. Las viñetas
+// ("- ", "* ") continúan con sangría.
+func wrapLine(line string, width int) []string {
+	if len([]rune(line)) <= width {
+		return []string{line}
+	}
+
+	indent := ""
+	trimmed := strings.TrimLeft(line, " ")
+	lead := line[:len(line)-len(trimmed)]
+	if strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") {
+		indent = lead + "  "
+	} else {
+		indent = lead
+	}
+
+	var out []string
+	current := lead
+	for _, word := range strings.Fields(trimmed) {
+		if strings.TrimSpace(current) != "" && len([]rune(current))+1+len([]rune(word)) > width {
+			out = append(out, current)
+			current = indent
+		}
+		if strings.TrimSpace(current) != "" {
+			current += " "
+		}
+		current += word
+	}
+	return append(out, current)
+}
+
+// checkCommitMessage devuelve avisos si el mensaje no respeta el formato
+// o el asunto supera subjectMax caracteres
+func checkCommitMessage(message string, subjectMax int) []string {
+	subject, _, _ := strings.Cut(message, "\n")
+	var warnings []string
+	if n := len([]rune(subject)); n > subjectMax {
+		warnings = append(warnings, fmt.Sprintf("el asunto tiene %d caracteres (máximo %d)", n, subjectMax))
+	}
+	if !conventionalSubject.MatchString(subject) {
+		warnings = append(warnings, "el asunto no sigue el formato tipo(ámbito): resumen")
+	}
+	return warnings
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatCommitMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"subject only", "  feat: add x  \n", "feat: add x"},
+		{"empty edit", "", ""},
+		{"blank edit", " \n\n\t\n", ""},
+		{
+			"fenced reply",
+			"```text\nfix(cli): close apps\n\nThe index command leaked the MCP servers.\n```",
+			"fix(cli): close apps\n\nThe index command leaked the MCP servers.",
+		},
+		{"quoted reply", `"docs: fix typo"`, "docs: fix typo"},
+		{"backticks around the message", "`chore: bump deps`", "chore: bump deps"},
+		{
+			"trailing backtick kept",
+			"refactor: rename cfg\n\nThe field is now `Config`",
+			"refactor: rename cfg\n\nThe field is now `Config`",
+		},
+		{"inner quotes kept", `"fix: handle "quoted" names"`, `"fix: handle "quoted" names"`},
+		{
+			"body wrapped, blank lines between subject and body collapsed",
+			"feat: x\n\n\n\n" + strings.Repeat("palabra ", 10) + "fin\n\n- " + strings.Repeat("viñeta ", 11) + "fin",
+			"feat: x\n\n" + strings.Repeat("palabra ", 8) + "palabra\npalabra fin\n\n- " +
+				strings.Repeat("viñeta ", 9) + "viñeta\n  viñeta fin",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatCommitMessage(tt.raw, 72); got != tt.want {
+				t.Errorf("formatCommitMessage() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{"fits", "corto", []string{"corto"}},
+		{"words", "aa bb cc dd ee", []string{"aa bb cc", "dd ee"}},
+		{"long word on its own line", "ver https://example.com/muy/larga fin", []string{"ver", "https://example.com/muy/larga", "fin"}},
+		{"dash bullet", "- uno dos tres cuatro", []string{"- uno dos", "  tres", "  cuatro"}},
+		{"star bullet indented", "  * uno dos tres", []string{"  * uno", "    dos", "    tres"}},
+		{"indented paragraph", "  uno dos tres cuatro", []string{"  uno dos", "  tres", "  cuatro"}},
+		{"runes, not bytes", "ñññññ ññññ", []string{"ñññññ ññññ"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapLine(tt.line, 10); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("wrapLine(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCommitMessage(t *testing.T) {
+	tests := []struct {
+		message string
+		want    []string
+	}{
+		{"feat(cli): add commit command\n\nbody", nil},
+		{"fix!: drop flag", nil},
+		{"revert: feat: x", nil},
+		{"Add commit command", []string{"formato"}},
+		{"feat:missing space", []string{"formato"}},
+		{"feature: x", []string{"formato"}},
+		{"feat: " + strings.Repeat("x", 40), []string{"46 caracteres (máximo 30)"}},
+		{strings.Repeat("ñ", 31), []string{"31 caracteres", "formato"}},
+	}
+	for _, tt := range tests {
+		got := checkCommitMessage(tt.message, 30)
+		if len(got) != len(tt.want) {
+			t.Errorf("checkCommitMessage(%q) = %q, want %d warnings", tt.message, got, len(tt.want))
+			continue
+		}
+		for i, w := range tt.want {
+			if !strings.Contains(got[i], w) {
+				t.Errorf("checkCommitMessage(%q)[%d] = %q, want it to mention %q", tt.message, i, got[i], w)
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"ollama-cli/internal/patch"
+	"ollama-cli/internal/tools"
+)
+
+// offerToApplyEdits detecta diffs unificados y bloques SEARCH/REPLACE en la
+// respuesta, verifica que apliquen sobre el archivo actual y ofrece
+// aplicarlos cambio por cambio.
+func (a *App) offerToApplyEdits(response string) {
+	for _, edit := range patch.Parse(response) {
+		current, err := tools.ReadFile(edit.Path)
+		switch {
+		case err == nil && edit.NewFile:
+			fmt.Printf("\n Se propone crear %s, pero ya existe; se omite\n", edit.Path)
+			continue
+		case err != nil && !errors.Is(err, fs.ErrNotExist):
+			fmt.Printf("\n No se pudo leer %s: %v\n", edit.Path, err)
+			continue
+		case err != nil:
+			current = ""
+		}
+
+		if edit.NewFile {
+			fmt.Printf("\n Archivo nuevo propuesto: %s\n", edit.Path)
+		} else {
+			fmt.Printf("\n Cambios propuestos para: %s (%d bloques)\n", edit.Path, len(edit.Hunks))
+		}
+		if _, err := patch.Apply(current, edit.Hunks); err != nil {
+			fmt.Printf(" Aviso: el conjunto no aplica limpiamente (%v); se ofrecen solo los bloques válidos\n", err)
+		}
+
+		result := current
+		shift := 0 // Desplazamiento de líneas por los bloques ya aplicados
+		applied := 0
+
+		for i, h := range edit.Hunks {
+			if h.OldStart > 0 {
+				h.OldStart += shift
+			}
+
+			fmt.Printf("\n ── Bloque %d/%d ──\n", i+1, len(edit.Hunks))
+			fmt.Print(patch.Format([]patch.Hunk{h}, tools.ColorEnabled()))
+
+			next, err := patch.Apply(result, []patch.Hunk{h})
+			if err != nil {
+				fmt.Printf(" No aplica: %v\n", err)
+				continue
+			}
+			if !tools.AskConfirmation("¿Aplicar este bloque?") {
+				continue
+			}
+
+			added, removed := h.Stats()
+			shift += added - removed
+			result = next
+			applied++
+		}
+
+		if applied == 0 {
+			continue
+		}
+		if err := tools.WriteFileDirectly(edit.Path, result); err != nil {
+			fmt.Printf(" Error guardando: %v\n", err)
+			continue
+		}
+		fmt.Printf(" Aplicados %d/%d bloques en %s\n", applied, len(edit.Hunks), edit.Path)
+	}
+}
+
+// reviewAndSave muestra en qué se diferencia la propuesta del archivo actual
+// y deja elegir: guardar, omitir, guardar con otro nombre o editar antes en
+// $EDITOR.
+func (a *App) reviewAndSave(filename, content string) {
+	for {
+		existing, err := tools.ReadFile(filename)
+		isNew := errors.Is(err, fs.ErrNotExist)
+		if err != nil && !isNew {
+			fmt.Printf("\n No se pudo leer %s: %v\n", filename, err)
+			return
+		}
+
+		diff := patch.Diff(existing, content)
+		added, removed := patch.Stats(diff)
+
+		if isNew {
+			fmt.Printf("\n Código detectado para: %s (archivo nuevo, %d líneas)\n", filename, added)
+		} else {
+			if added == 0 && removed == 0 {
+				fmt.Printf("\n Código detectado para: %s (sin cambios)\n", filename)
+				return
+			}
+			fmt.Printf("\n Código detectado para: %s (+%d -%d)\n", filename, added, removed)
+			fmt.Print(patch.Format(patch.Group(diff, 3), tools.ColorEnabled()))
+		}
+
+		choice := tools.AskChoice(
+			fmt.Sprintf("¿Guardar '%s'? [s]í / [n]o / guardar [c]omo / [e]ditar", filename),
+			"s", "c", "e", "n")
+
+		switch choice {
+		case "s":
+			if err := tools.WriteFileDirectly(filename, content); err != nil {
+				fmt.Printf(" Error guardando: %v\n", err)
+				return
+			}
+			fmt.Printf(" Guardado: %s\n", filename)
+			return
+
+		case "c":
+			if path := tools.AskInput("Guardar como"); path != "" {
+				filename = path
+			}
+
+		case "e":
+			edited, err := tools.EditInEditor(content, filepath.Ext(filename))
+			if err != nil {
+				fmt.Printf(" Error: %v\n", err)
+				continue
+			}
+			content = edited
+
+		default:
+			return
+		}
+	}
+}
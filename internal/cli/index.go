@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"ollama-cli/internal/index"
+	"ollama-cli/internal/llm"
+	"ollama-cli/internal/mcp"
+)
+
+// BuildIndex crea o actualiza el índice semántico del proyecto en workDir.
+// Solo se vuelven a procesar los archivos que cambiaron.
+func (a *App) BuildIndex(ctx context.Context, workDir string) error {
+	embedder, ok := a.client.(llm.Embedder)
+	if !ok {
+		return fmt.Errorf("el backend configurado no admite embeddings; usa ollama")
+	}
+
+	files, err := mcp.ProjectFiles(ctx, workDir, a.cfg.MaxDepth)
+	if err != nil {
+		return err
+	}
+
+	ix, err := index.Load(workDir)
+	switch {
+	case errors.Is(err, index.ErrNotFound):
+		ix = index.New(a.cfg.EmbedModel)
+	case err != nil:
+		return err
+	case ix.Model != a.cfg.EmbedModel:
+		// Vectores de modelos distintos no son comparables
+		fmt.Fprintf(os.Stderr, "El modelo de embeddings cambió (%s → %s); se reconstruye el índice\n", ix.Model, a.cfg.EmbedModel)
+		ix = index.New(a.cfg.EmbedModel)
+	}
+
+	stats, err := ix.Update(ctx, workDir, files, embedder, func(path string) {
+		fmt.Fprintf(os.Stderr, "  %s\n", path)
+	})
+	// Guardar lo avanzado aunque haya fallado, para retomar después
+	if saveErr := ix.Save(workDir); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Índice actualizado: %d nuevos, %d modificados, %d eliminados, %d sin cambios (%d fragmentos)\n",
+		stats.Added, stats.Changed, stats.Removed, stats.Unchanged, len(ix.Chunks))
+	return nil
+}
+
+// IndexStatus muestra el estado del índice de workDir y cuántos archivos
+// cambiaron desde la última actualización.
+func (a *App) IndexStatus(ctx context.Context, workDir string) error {
+	ix, err := index.Load(workDir)
+	if errors.Is(err, index.ErrNotFound) {
+		fmt.Println("No hay índice en este proyecto. Créalo con: oli index build")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	files, err := mcp.ProjectFiles(ctx, workDir, a.cfg.MaxDepth)
+	if err != nil {
+		return err
+	}
+	changed, removed := ix.Stale(workDir, files)
+
+	fmt.Printf("Índice:      %s\n", index.Path(workDir))
+	fmt.Printf("Modelo:      %s\n", ix.Model)
+	fmt.Printf("Archivos:    %d\n", len(ix.Files))
+	fmt.Printf("Fragmentos:  %d\n", len(ix.Chunks))
+	fmt.Printf("Actualizado: %s\n", ix.Updated.Format("2006-01-02 15:04"))
+	if changed > 0 || removed > 0 {
+		fmt.Printf("Pendientes:  %d nuevos o modificados, %d eliminados (oli index build)\n", changed, removed)
+	} else {
+		fmt.Println("Al día.")
+	}
+	return nil
+}
+
+// ClearIndex borra el índice de workDir.
+func (a *App) ClearIndex(workDir string) error {
+	err := index.Clear(workDir)
+	if errors.Is(err, index.ErrNotFound) {
+		fmt.Println("No hay índice en este proyecto.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("Índice eliminado.")
+	return nil
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+
+	"ollama-cli/internal/llm"
+	"ollama-cli/internal/mcp"
+	"ollama-cli/internal/tools"
+)
+
+// connectMCP lanza los servidores de Config.MCPServers la primera vez que
+// se necesitan: sus recursos pasan a ser proveedores de contexto y sus
+// herramientas quedan disponibles en modo agente. Un servidor que falla se
+// informa y se omite.
+func (a *App) connectMCP(ctx context.Context) {
+	if a.mcpStarted {
+		return
+	}
+	a.mcpStarted = true
+
+	for _, cfg := range a.cfg.MCPServers {
+		client, err := mcp.StartServer(ctx, mcp.ServerConfig{
+			Name:    cfg.Name,
+			Command: cfg.Command,
+			Args:    cfg.Args,
+			Env:     cfg.Env,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Aviso: servidor MCP %s: %v\n", cfg.Name, err)
+			continue
+		}
+		a.mcpClients = append(a.mcpClients, client)
+		a.providers = append(a.providers, mcp.NewResourceProvider(client, cfg.Resources))
+
+		serverTools, err := client.ListTools(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Aviso: servidor MCP %s: %v\n", cfg.Name, err)
+			continue
+		}
+		for _, t := range serverTools {
+			a.mcpTools = append(a.mcpTools, mcpAgentTool(client, t, slices.Contains(cfg.AllowTools, t.Name)))
+		}
+	}
+}
+
+// invalidToolChars son los caracteres que los backends no aceptan en el
+// nombre de una herramienta
+var invalidToolChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// mcpAgentTool adapta una herramienta de un servidor MCP al modo agente.
+// El nombre lleva el del servidor como prefijo para evitar choques.
+func mcpAgentTool(client *mcp.Client, t mcp.ToolInfo, allowed bool) agentTool {
+	name := invalidToolChars.ReplaceAllString(client.Name()+"_"+t.Name, "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	schema := t.InputSchema
+	if schema == nil {
+		schema = objectSchema(map[string]any{})
+	}
+
+	return agentTool{
+		def: llm.Tool{
+			Name:        name,
+			Description: fmt.Sprintf("[MCP %s] %s", client.Name(), t.Description),
+			Parameters:  schema,
+		},
+		run: func(ctx context.Context, args map[string]any) (string, error) {
+			if !allowed && !tools.AskConfirmation(fmt.Sprintf("¿Ejecutar la herramienta %s del servidor MCP %s?", t.Name, client.Name())) {
+				return "", fmt.Errorf("el usuario rechazó la llamada")
+			}
+			result, err := client.CallTool(ctx, t.Name, args)
+			if err != nil {
+				return "", err
+			}
+			if result.IsError {
+				return "", fmt.Errorf("%s", result.Text())
+			}
+			return result.Text(), nil
+		},
+	}
+}
+
+// ListMCP muestra las herramientas, recursos y prompts de cada servidor
+// configurado
+func (a *App) ListMCP(ctx context.Context) {
+	if len(a.cfg.MCPServers) == 0 {
+		fmt.Println("No hay servidores MCP configurados (mcp_servers en la configuración).")
+		return
+	}
+	a.connectMCP(ctx)
+
+	for _, client := range a.mcpClients {
+		info := client.ServerInfo()
+		fmt.Printf("\n %s (%s %s)\n", client.Name(), info.Name, info.Version)
+
+		serverTools, err := client.ListTools(ctx)
+		printMCPError(err)
+		for _, t := range serverTools {
+			fmt.Printf("   herramienta  %-24s %s\n", t.Name, t.Description)
+		}
+		resources, err := client.ListResources(ctx)
+		printMCPError(err)
+		for _, r := range resources {
+			fmt.Printf("   recurso      %-24s %s\n", r.URI, r.Name)
+		}
+		prompts, err := client.ListPrompts(ctx)
+		printMCPError(err)
+		for _, p := range prompts {
+			fmt.Printf("   prompt       %-24s %s\n", p.Name, p.Description)
+		}
+	}
+	fmt.Println()
+}
+
+func printMCPError(err error) {
+	if err != nil {
+		fmt.Printf("   error: %v\n", err)
+	}
+}
+
+// Close detiene los servidores MCP lanzados por la app
+func (a *App) Close() {
+	for _, client := range a.mcpClients {
+		client.Close()
+	}
+	a.mcpClients = nil
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"ollama-cli/internal/ignore"
+	"ollama-cli/internal/mcp"
+	"ollama-cli/internal/tools"
+)
+
+// ServeMCP atiende a un cliente MCP por r y w: los proveedores filesystem y
+// git se ofrecen como recursos y read_file, list_dir y write_file como
+// herramientas. write_file solo escribe los archivos que permite
+// Config.MCPServeWrite más los patrones de allowWrite; sin ninguno no se
+// ofrece. Nunca se pide confirmación, ya que stdin es el canal del cliente.
+func (a *App) ServeMCP(ctx context.Context, r io.Reader, w io.Writer, allowWrite []string) error {
+	workDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+	// Un cliente remoto nunca sale del proyecto, aunque OLI_ALLOW_OUTSIDE lo permita
+	tools.AllowOutsideWorkspace(false)
+
+	var providers []mcp.ContextProvider
+	for _, p := range a.providers {
+		if p.Name() == "filesystem" || p.Name() == "git" {
+			providers = append(providers, p)
+		}
+	}
+
+	var serverTools []mcp.ServerTool
+	for _, t := range a.agentTools() {
+		if t.def.Name == "read_file" || t.def.Name == "list_dir" {
+			serverTools = append(serverTools, mcp.ServerTool{
+				Info: mcp.ToolInfo{Name: t.def.Name, Description: t.def.Description, InputSchema: t.def.Parameters},
+				Run:  t.run,
+			})
+		}
+	}
+
+	patterns := append(slices.Clone(a.cfg.MCPServeWrite), allowWrite...)
+	if len(patterns) > 0 {
+		serverTools = append(serverTools, writeServerTool(patterns))
+	}
+
+	server := mcp.NewServer(workDir, providers, serverTools)
+	server.SetInstructions(fmt.Sprintf("oli sirve el contexto del proyecto %s. Lee oli://context/filesystem?task=<tarea> para obtener los archivos relevantes a una tarea.", workDir))
+	fmt.Fprintf(os.Stderr, "oli mcp: sirviendo %s por stdio\n", workDir)
+	return server.Serve(ctx, r, w)
+}
+
+// writeServerTool crea la herramienta write_file de "oli mcp serve", que
+// solo escribe rutas del proyecto que coinciden con patterns (sintaxis de
+// .gitignore; ver writeAllowed)
+func writeServerTool(patterns []string) mcp.ServerTool {
+	allowed := ignore.New()
+	allowed.Add("", patterns)
+
+	return mcp.ServerTool{
+		Info: mcp.ToolInfo{
+			Name:        "write_file",
+			Description: "Crea o reemplaza un archivo del proyecto. Solo se permiten las rutas que coinciden con: " + strings.Join(patterns, ", "),
+			InputSchema: objectSchema(map[string]any{
+				"path":    stringSchema("Ruta del archivo, relativa a la raíz del proyecto"),
+				"content": stringSchema("Contenido completo del archivo"),
+			}, "path", "content"),
+		},
+		Run: func(ctx context.Context, args map[string]any) (string, error) {
+			path, err := stringArg(args, "path")
+			if err != nil {
+				return "", err
+			}
+			content, err := stringArg(args, "content")
+			if err != nil {
+				return "", err
+			}
+
+			resolved, err := tools.Resolve(path)
+			if err != nil {
+				return "", err
+			}
+			rel, err := filepath.Rel(tools.WorkspaceRoot(), resolved)
+			if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+				return "", fmt.Errorf("%s está fuera del proyecto", path)
+			}
+			if !writeAllowed(allowed, filepath.ToSlash(rel)) {
+				return "", fmt.Errorf("la política del servidor no permite escribir %s", filepath.ToSlash(rel))
+			}
+
+			if err := tools.WriteFileDirectly(resolved, content); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Archivo guardado: %s (%d bytes)", filepath.ToSlash(rel), len(content)), nil
+		},
+	}
+}
+
+// writeAllowed indica si allowed permite escribir rel. Como walkProject
+// con un directorio ignorado, un directorio que coincide cubre todo su
+// contenido, de modo que "docs/" permite escribir docs/guia/uso.md.
+func writeAllowed(allowed *ignore.Matcher, rel string) bool {
+	for i := range len(rel) {
+		if rel[i] == '/' && allowed.Match(rel[:i], true) {
+			return true
+		}
+	}
+	return allowed.Match(rel, false)
+}
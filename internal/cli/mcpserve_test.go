@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ollama-cli/internal/ignore"
+	"ollama-cli/internal/tools"
+)
+
+func TestWriteAllowed(t *testing.T) {
+	allowed := ignore.New()
+	allowed.Add("", []string{"docs/", "*.md", "!README.md", "/gen/out.txt"})
+
+	tests := []struct {
+		rel  string
+		want bool
+	}{
+		{"docs/guia.txt", true},
+		{"docs/guia/uso.go", true},
+		{"src/docs/a.go", true},
+		{"notes.md", true},
+		{"src/notes.md", true},
+		{"README.md", false},
+		{"gen/out.txt", true},
+		{"src/gen/out.txt", false},
+		{"main.go", false},
+		{"docs", false},
+		{"docsx/a.go", false},
+	}
+	for _, tt := range tests {
+		if got := writeAllowed(allowed, tt.rel); got != tt.want {
+			t.Errorf("writeAllowed(%q) = %v, want %v", tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestWriteServerToolPolicy(t *testing.T) {
+	root := t.TempDir()
+	if err := tools.SetWorkspace(root); err != nil {
+		t.Fatal(err)
+	}
+	root = tools.WorkspaceRoot()
+	t.Cleanup(func() { tools.SetWorkspace(".") })
+
+	tool := writeServerTool([]string{"docs/"})
+	write := func(rel string) error {
+		_, err := tool.Run(context.Background(), map[string]any{
+			"path":    filepath.Join(root, rel),
+			"content": "hola",
+		})
+		return err
+	}
+
+	if err := write("docs/guia/uso.md"); err != nil {
+		t.Fatalf("docs/guia/uso.md: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(root, "docs", "guia", "uso.md")); string(got) != "hola" {
+		t.Errorf("content = %q", got)
+	}
+
+	err := write("main.go")
+	if err == nil || !strings.Contains(err.Error(), "no permite") {
+		t.Errorf("main.go: err = %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(root, "main.go")); !os.IsNotExist(statErr) {
+		t.Errorf("main.go was written")
+	}
+}
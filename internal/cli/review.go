@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"ollama-cli/internal/budget"
+	"ollama-cli/internal/llm"
+	"ollama-cli/internal/mcp"
+	"ollama-cli/internal/review"
+)
+
+// maxReviewFileSize limita el contenido de cada archivo tocado que se envía
+const maxReviewFileSize = 50000
+
+// Review pide al modelo una revisión de los cambios de revRange (como en
+// git diff; vacío = cambios sin confirmar) y devuelve las observaciones
+// ordenadas por archivo y línea. Sin cambios devuelve nil.
+func (a *App) Review(ctx context.Context, revRange string) ([]review.Finding, error) {
+	workDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("get working directory: %w", err)
+	}
+
+	git := a.gitProvider()
+	diff, err := git.RangeDiff(ctx, workDir, revRange)
+	if err != nil {
+		return nil, fmt.Errorf("git diff: %w", err)
+	}
+	if strings.TrimSpace(diff) == "" {
+		fmt.Fprintln(os.Stderr, "No hay cambios que revisar.")
+		return nil, nil
+	}
+
+	files, err := git.ChangedFiles(ctx, workDir, revRange)
+	if err != nil {
+		return nil, fmt.Errorf("git diff: %w", err)
+	}
+
+	// El diff va primero; el contenido de los archivos se recorta antes
+	var diffSections, fileSections []string
+	for _, d := range mcp.SplitDiff(diff) {
+		fence := mcp.CodeFence(d.Text)
+		diffSections = append(diffSections, fmt.Sprintf("### %s\n%sdiff\n%s%s", d.Path, fence, d.Text, fence))
+	}
+	head := mcp.RangeHead(revRange)
+	for _, path := range files {
+		content, err := git.FileAt(ctx, workDir, head, path)
+		if err != nil || len(content) > maxReviewFileSize {
+			continue
+		}
+		fence := mcp.CodeFence(content)
+		fileSections = append(fileSections, fmt.Sprintf("### %s\n%s\n%s\n%s", path, fence, content, fence))
+	}
+	contexts := []mcp.ContextResult{
+		{Provider: "diff", Content: strings.Join(diffSections, "\n\n")},
+		{Provider: "archivos modificados", Content: strings.Join(fileSections, "\n\n")},
+	}
+
+	_, available := a.contextBudget(ctx, a.cfg.ReviewTask, a.cfg.ReviewFormat)
+	contexts, report := budget.Allocate(contexts, available, func(provider string) int {
+		if provider == "diff" {
+			return 1
+		}
+		return 0
+	})
+	if report.Trimmed() {
+		fmt.Fprintln(os.Stderr, report.String())
+	}
+	a.contextSummary = summarizeContext(contexts)
+
+	system, user := a.build(ctx, contexts, a.cfg.ReviewTask)
+	system += "\n\n" + a.cfg.ReviewFormat
+
+	fmt.Fprintf(os.Stderr, "Revisando %d archivo(s)...\n", len(diffSections))
+	reply, err := a.client.Chat(ctx, llm.ChatRequest{
+		Model: a.model,
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: system},
+			{Role: llm.RoleUser, Content: user},
+		},
+		Options: &a.options,
+	}, func(string) {})
+	if err != nil {
+		return nil, err
+	}
+
+	findings, err := review.Parse(reply.Content)
+	if err != nil {
+		return nil, fmt.Errorf("%w; respuesta del modelo:\n%s", err, reply.Content)
+	}
+	return findings, nil
+}
+
+// gitProvider devuelve el proveedor de git de la app
+func (a *App) gitProvider() *mcp.GitProvider {
+	for _, p := range a.providers {
+		if git, ok := p.(*mcp.GitProvider); ok {
+			return git
+		}
+	}
+	return mcp.NewGitProvider()
+}
@@ -1,68 +1,327 @@
 package config
 
+import "ollama-cli/internal/llm"
+
 // ============================================================================
-// CONFIGURACIÓN DE OLI - Modifica estos valores según tus necesidades
+// CONFIGURACIÓN DE OLI
 // ============================================================================
+// Los valores de Default se combinan, en este orden, con:
+//   1. ~/.config/oli/config.toml (o config.json)
+//   2. .oli/config.toml, .oli/config.json o .oli/config del proyecto
+//   3. variables de entorno (OLLAMA_MODEL, OLLAMA_URL, OLI_BACKEND, OPENAI_BASE_URL)
+//   4. flags de la línea de comandos
+// Las claves de los archivos son las etiquetas json de Config; el archivo
+// del proyecto no puede fijar las que lanzan procesos o dan permisos (ver
+// userOnlyKeys). "oli config show" muestra los valores efectivos y de
+// dónde viene cada uno.
 
-// Modelo por defecto de Ollama
-var Model = "qwen2.5-coder:14b"
+// Config es la configuración efectiva de oli
+type Config struct {
+	// Modelo por defecto de Ollama
+	Model string `json:"model"`
 
-// URL del servidor Ollama
-var OllamaURL = "http://localhost:11434"
+	// URL del servidor Ollama
+	OllamaURL string `json:"ollama_url"`
 
-// Máximo de archivos a leer (contenido completo)
-var MaxFiles = 30
+	// Backend: "ollama" o "openai" (cualquier servidor compatible con
+	// /v1/chat/completions: llama.cpp server, vLLM, LM Studio...)
+	Backend string `json:"backend"`
 
-// Profundidad máxima de carpetas a explorar
-var MaxDepth = 4
+	// URL base del servidor compatible con OpenAI, incluyendo /v1.
+	// La API key se lee de la variable de entorno OPENAI_API_KEY.
+	OpenAIURL string `json:"openai_url"`
 
-// ============================================================================
-// PROMPTS DEL SISTEMA - Personaliza el comportamiento del asistente
-// ============================================================================
+	// Opciones de generación por defecto. NumCtx es la ventana de contexto
+	// en tokens: el valor por defecto de Ollama (2048) recorta en silencio
+	// el contexto del proyecto. Sin valor = lo decide el backend.
+	Options llm.Options `json:"options"`
 
-// Prompt principal del sistema
-var SystemPrompt = `Eres un asistente de programación experto. Analizas código y das sugerencias.
+	// Ventana de contexto (tokens) a suponer cuando no se puede consultar
+	// al backend ni se fijó Options.NumCtx
+	ContextWindow int `json:"context_window"`
 
-REGLAS:
-- Cuando crees o modifiques archivos, usa este formato para que se puedan guardar automáticamente:
+	// Porcentaje de la ventana reservado para la respuesta del modelo
+	ResponseReserve int `json:"response_reserve"`
 
-**nombre_archivo.ext**
-` + "```" + `lenguaje
-contenido del archivo
-` + "```" + `
+	// Prioridad de cada proveedor al repartir la ventana de contexto: los
+	// de mayor prioridad se incluyen completos primero; el resto se recorta.
+	ProviderPriority map[string]int `json:"provider_priority"`
 
-- Siempre muestra el contenido completo del archivo.
-- Cuando sugieras comandos, explica qué hacen.
+	// Cuándo incluir el diff de los cambios en el contexto de git: "auto"
+	// (si la pregunta contiene alguna de DiffKeywords), "always" o "never"
+	GitDiff string `json:"git_diff"`
 
-Sé conciso. Enfócate en la tarea específica del usuario.
-Responde en español.`
+	// Rama o ref base opcional: se agrega también el diff base...HEAD
+	GitDiffBase string `json:"git_diff_base"`
 
-// ============================================================================
-// PROMPTS ADICIONALES - Puedes agregar más prompts para diferentes usos
-// ============================================================================
+	// Palabras que, en modo "auto", hacen que se envíe el diff. Se comparan
+	// palabras completas: "diff" no coincide con "different"
+	DiffKeywords []string `json:"diff_keywords"`
+
+	// Profundidad de carpetas que recorre el esquema de paquetes Go
+	OutlineDepth int `json:"outline_depth"`
+
+	// Modelo de embeddings para el índice semántico (oli index build)
+	EmbedModel string `json:"embed_model"`
+
+	// Fragmentos del índice que se agregan al contexto en cada pregunta
+	IndexTopK int `json:"index_top_k"`
+
+	// Máximo de archivos a leer (contenido completo)
+	MaxFiles int `json:"max_files"`
+
+	// Profundidad máxima de carpetas a explorar
+	MaxDepth int `json:"max_depth"`
+
+	// Máximo de pasos (llamadas a herramientas) en modo agente
+	AgentMaxSteps int `json:"agent_max_steps"`
+
+	// Máximo de bytes del resultado de una herramienta que se devuelven al modelo
+	MaxToolOutput int `json:"max_tool_output"`
+
+	// Permisos de comandos (run / modo agente). Cada patrón son palabras
+	// que deben coincidir con el inicio del comando; "*" coincide con una
+	// palabra y "**" con varias. Gana la regla más específica. Los
+	// comandos que no coinciden con ninguna regla piden confirmación.
+	CommandAllow   []string `json:"command_allow"`   // se ejecutan sin preguntar
+	CommandConfirm []string `json:"command_confirm"` // requieren confirmación
+	CommandDeny    []string `json:"command_deny"`    // bloqueados
+
+	// Tiempo máximo de ejecución de un comando, en segundos
+	CommandTimeout int `json:"command_timeout"`
+
+	// Permitir que las herramientas lean y escriban fuera del directorio
+	// del proyecto (--allow-outside, OLI_ALLOW_OUTSIDE=1)
+	AllowOutside bool `json:"allow_outside"`
+
+	// Idioma de las respuestas; los prompts lo usan como {{.Language}}
+	Language string `json:"language"`
+
+	// Prompt principal del sistema. Los prompts son plantillas de
+	// text/template con {{.Task}}, {{.Branch}}, {{.Language}}, {{.Model}},
+	// {{.Prompt}} y {{range .Contexts}}; pueden redefinir el mensaje del
+	// usuario con {{define "user"}} e incluir fragmentos de prompts/snippets/
+	// con {{template "nombre" .}}.
+	SystemPrompt string `json:"system_prompt"`
+
+	// Instrucciones que se agregan al prompt del sistema en modo agente
+	AgentPrompt string `json:"agent_prompt"`
+
+	// Instrucciones de formato que se agregan al prompt "code-review" en
+	// "oli review", para poder agrupar y filtrar las observaciones
+	ReviewFormat string `json:"review_format"`
+
+	// Tarea que se envía en "oli review" junto con el diff
+	ReviewTask string `json:"review_task"`
+
+	// Severidad a partir de la cual "oli review" termina con código de salida 1
+	ReviewFailOn string `json:"review_fail_on"`
+
+	// Servidores MCP cuyos recursos se agregan al contexto y cuyas
+	// herramientas quedan disponibles en modo agente
+	MCPServers []MCPServer `json:"mcp_servers"`
+
+	// Archivos que la herramienta write_file de "oli mcp serve" puede
+	// escribir, con la sintaxis de .gitignore relativa al proyecto
+	// ("docs/**", "*.md", "!secret.md"). Vacío = los clientes MCP no
+	// pueden escribir.
+	MCPServeWrite []string `json:"mcp_serve_write"`
+
+	// Prompt de "oli commit" para redactar el mensaje a partir del diff preparado
+	CommitPrompt string `json:"commit_prompt"`
+
+	// Longitud máxima del asunto y ancho del cuerpo en "oli commit"
+	CommitSubjectMax int `json:"commit_subject_max"`
+	CommitBodyWidth  int `json:"commit_body_width"`
+
+	// Prompts adicionales para diferentes usos ("default" es SystemPrompt)
+	Prompts map[string]string `json:"prompts"`
+
+	// Opciones de generación por prompt; se combinan con Options
+	PromptOptions map[string]llm.Options `json:"prompt_options"`
 
-var Prompts = map[string]string{
-	"default": SystemPrompt,
+	// sources guarda de qué capa viene cada clave (ver Source)
+	sources map[string]string
+	// files son los archivos de configuración leídos, en orden
+	files []string
+	// profiles son los prompts leídos de archivos .md (ver Profile) y
+	// snippets los fragmentos que pueden incluir
+	profiles map[string]Profile
+	snippets map[string]string
+}
+
+// MCPServer es un servidor MCP que oli lanza por stdio. Ejemplo en TOML:
+//
+//	[[mcp_servers]]
+//	name = "docs"
+//	command = "npx"
+//	args = ["-y", "@modelcontextprotocol/server-filesystem", "./docs"]
+type MCPServer struct {
+	Name    string            `json:"name"`
+	Command string            `json:"command"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+
+	// Resources son las URIs que se agregan al contexto; vacío = todas
+	Resources []string `json:"resources,omitempty"`
+
+	// AllowTools son las herramientas que el agente usa sin pedir
+	// confirmación; las demás se confirman en cada llamada
+	AllowTools []string `json:"allow_tools,omitempty"`
+}
+
+// Default devuelve la configuración predeterminada, la primera capa de Load
+func Default() *Config {
+	return &Config{
+		Model:     "qwen2.5-coder:14b",
+		OllamaURL: "http://localhost:11434",
+		Backend:   "ollama",
+		OpenAIURL: "http://localhost:8080/v1",
+		Options: llm.Options{
+			NumCtx: 8192,
+		},
+		ContextWindow:   8192,
+		ResponseReserve: 25,
+		ProviderPriority: map[string]int{
+			"git":        100,
+			"go-outline": 70,
+			"mcp":        60,
+			"index":      50,
+			"filesystem": 10,
+		},
+		GitDiff:     "auto",
+		GitDiffBase: "",
+		DiffKeywords: []string{
+			"review", "revisa", "revisar", "revisión", "cambio", "cambios",
+			"change", "changes", "changed", "diff", "commit", "commits",
+			"staged", "modifiqué", "cambié",
+		},
+		OutlineDepth:  8,
+		EmbedModel:    "nomic-embed-text",
+		IndexTopK:     8,
+		MaxFiles:      30,
+		MaxDepth:      4,
+		AgentMaxSteps: 10,
+		MaxToolOutput: 20000,
+
+		CommandAllow: []string{
+			"ls", "cat", "head", "tail", "grep", "find", "wc",
+			"pwd", "echo", "which",
+			"git status", "git log", "git diff", "git show",
+			"git branch --show-current", "git branch --list",
+		},
+		CommandConfirm: []string{
+			"mkdir", "touch", "cp", "mv",
+			"git add", "git commit",
+			"go build", "go test", "go get", "go mod",
+			"npm install", "npm run", "pip install", "make",
+			"find ** -delete", "find ** -exec", "find ** -execdir",
+			"git ** --output",
+		},
+		CommandDeny: []string{
+			"sudo", "su", "rm ** -rf", "rm ** -fr", "rm ** -r", "chmod", "chown", "dd", "mkfs",
+			"git push", "git reset ** --hard",
+		},
+		CommandTimeout: 120,
+
+		Language:     "español",
+		SystemPrompt: systemPrompt,
+		AgentPrompt:  agentPrompt,
+		ReviewFormat: reviewFormat,
+		ReviewTask:   "Revisa los cambios del diff. Usa el contenido de los archivos solo como referencia.",
+		ReviewFailOn: "high",
+
+		MCPServers:    []MCPServer{},
+		MCPServeWrite: []string{},
 
-	"code-review": `Eres un revisor de código experto. Tu trabajo es:
+		CommitPrompt:     commitPrompt,
+		CommitSubjectMax: 72,
+		CommitBodyWidth:  72,
+
+		Prompts: map[string]string{
+			"default": systemPrompt,
+
+			"code-review": `Eres un revisor de código experto. Tu trabajo es:
 - Identificar bugs y problemas potenciales
 - Sugerir mejoras de rendimiento
 - Verificar mejores prácticas
 - Detectar problemas de seguridad
 Sé específico y muestra ejemplos de código corregido.
-Responde en español.`,
+Responde en {{.Language}}.`,
 
-	"explainer": `Eres un profesor de programación paciente. Tu trabajo es:
+			"explainer": `Eres un profesor de programación paciente. Tu trabajo es:
 - Explicar código de forma clara y sencilla
 - Usar analogías cuando sea útil
 - Dividir conceptos complejos en partes simples
 - Dar ejemplos prácticos
-Responde en español.`,
+Responde en {{.Language}}.`,
 
-	"architect": `Eres un arquitecto de software senior. Tu trabajo es:
+			"architect": `Eres un arquitecto de software senior. Tu trabajo es:
 - Analizar la estructura del proyecto
 - Sugerir patrones de diseño apropiados
 - Identificar problemas de arquitectura
 - Proponer mejoras escalables
-Responde en español.`,
+Responde en {{.Language}}.`,
+		},
+
+		// Las revisiones usan temperatura 0 y semilla fija para ser reproducibles
+		PromptOptions: map[string]llm.Options{
+			"code-review": {Temperature: float(0), Seed: integer(42)},
+		},
+	}
 }
+
+// ============================================================================
+// PROMPTS PREDETERMINADOS
+// ============================================================================
+
+const systemPrompt = `Eres un asistente de programación experto. Analizas código y das sugerencias.
+
+REGLAS:
+- Cuando crees o modifiques archivos, usa este formato para que se puedan guardar automáticamente:
+
+**nombre_archivo.ext**
+` + "```" + `lenguaje
+contenido del archivo
+` + "```" + `
+
+- Para archivos nuevos o reescrituras, muestra el contenido completo del archivo.
+- Para cambiar partes de un archivo existente, usa bloques SEARCH/REPLACE
+  copiando exactamente las líneas actuales:
+
+nombre_archivo.ext
+<<<<<<< SEARCH
+líneas actuales
+=======
+líneas nuevas
+>>>>>>> REPLACE
+
+- Cuando sugieras comandos, explica qué hacen.
+
+Sé conciso. Enfócate en la tarea específica del usuario.
+Responde en {{.Language}}.`
+
+const agentPrompt = `MODO AGENTE:
+- Tienes herramientas para leer, listar y escribir archivos del proyecto,
+  y para ejecutar comandos (sin shell: nada de tuberías ni redirecciones).
+- Úsalas para investigar antes de responder; no inventes el contenido de archivos.
+- Para modificar un archivo, usa write_file con el contenido completo.
+- Cuando termines, responde con un resumen de lo que hiciste.`
+
+const reviewFormat = `FORMATO DE RESPUESTA:
+Responde SOLO con un objeto JSON, sin texto adicional:
+{"findings": [{"file": "ruta/relativa.go", "line": 42, "severity": "high", "message": "..."}]}
+- severity: critical, high, medium, low o info.
+- line: línea del archivo en la versión nueva (0 si no aplica).
+- Comenta solo los cambios del diff; si no hay problemas, devuelve {"findings": []}.`
+
+const commitPrompt = `Redactas mensajes de commit con el formato Conventional Commits.
+- Primera línea: <tipo>(<ámbito opcional>): <resumen en imperativo>, sin punto final.
+  Tipos: feat, fix, docs, style, refactor, perf, test, build, ci, chore, revert.
+- Luego una línea en blanco y, si hace falta, un cuerpo breve que explique
+  qué cambia y por qué (no cómo).
+- Responde SOLO con el mensaje, sin comillas ni bloques de código.`
+
+func float(v float64) *float64 { return &v }
+
+func integer(v int) *int { return &v }
@@ -0,0 +1,281 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SourceDefault es el origen de los valores que ninguna capa cambió
+const SourceDefault = "predeterminado"
+
+// envVars son las variables de entorno que sobrescriben claves de la
+// configuración
+var envVars = []struct{ name, key string }{
+	{"OLLAMA_MODEL", "model"},
+	{"OLLAMA_URL", "ollama_url"},
+	{"OLI_BACKEND", "backend"},
+	{"OPENAI_BASE_URL", "openai_url"},
+}
+
+// userOnlyKeys son las claves que un archivo del proyecto no puede fijar:
+// lanzan procesos o amplían lo que oli puede tocar, y un repositorio
+// clonado no debería decidirlo. Se admiten en el archivo del usuario, el
+// entorno y los flags.
+var userOnlyKeys = []string{
+	"mcp_servers",
+	"command_allow",
+	"command_confirm",
+	"command_deny",
+	"allow_outside",
+	"mcp_serve_write",
+}
+
+// UserDir devuelve el directorio de configuración del usuario
+// ($XDG_CONFIG_HOME/oli o ~/.config/oli)
+func UserDir() string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "oli")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "oli")
+}
+
+// Load combina Default con el archivo del usuario, el del proyecto en
+// workDir y las variables de entorno, y lee los prompts en Markdown de
+// prompts/ (y sus fragmentos de prompts/snippets/) en ambos directorios.
+// Los flags se aplican después con Set.
+func Load(workDir string) (*Config, error) {
+	c := Default()
+
+	if dir := UserDir(); dir != "" {
+		err := c.loadFirst([]string{
+			filepath.Join(dir, "config.toml"),
+			filepath.Join(dir, "config.json"),
+		}, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := c.loadFirst([]string{
+		filepath.Join(workDir, ".oli", "config.toml"),
+		filepath.Join(workDir, ".oli", "config.json"),
+		filepath.Join(workDir, ".oli", "config"),
+	}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, env := range envVars {
+		if v := os.Getenv(env.name); v != "" {
+			if err := c.Set(env.key, v, "env "+env.name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if v := os.Getenv("OLI_ALLOW_OUTSIDE"); v != "" {
+		if err := c.Set("allow_outside", v == "1" || v == "true", "env OLI_ALLOW_OUTSIDE"); err != nil {
+			return nil, err
+		}
+	}
+
+	var promptDirs []string
+	if dir := UserDir(); dir != "" {
+		promptDirs = append(promptDirs, filepath.Join(dir, "prompts"))
+	}
+	promptDirs = append(promptDirs, filepath.Join(workDir, ".oli", "prompts"))
+	if err := c.loadProfiles(promptDirs...); err != nil {
+		return nil, err
+	}
+	snippetDirs := make([]string, len(promptDirs))
+	for i, dir := range promptDirs {
+		snippetDirs[i] = filepath.Join(dir, "snippets")
+	}
+	if err := c.loadSnippets(snippetDirs...); err != nil {
+		return nil, err
+	}
+
+	// El prompt "default" sigue a SystemPrompt salvo que se defina aparte
+	if c.Source("prompts.default") == SourceDefault {
+		c.Prompts["default"] = c.SystemPrompt
+	}
+	return c, nil
+}
+
+// loadFirst aplica el primero de files que exista. Si project es true,
+// rechaza las claves de userOnlyKeys.
+func (c *Config) loadFirst(files []string, project bool) error {
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		var values map[string]any
+		if strings.HasSuffix(file, ".json") || (filepath.Ext(file) == "" && bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))) {
+			err = json.Unmarshal(data, &values)
+		} else {
+			values, err = parseTOML(string(data))
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if project {
+			for _, key := range userOnlyKeys {
+				if _, ok := values[key]; ok {
+					return fmt.Errorf("%s: %q solo puede fijarse en la configuración del usuario, el entorno o los flags", file, key)
+				}
+			}
+		}
+		if err := c.apply(values, file); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		c.files = append(c.files, file)
+		return nil
+	}
+	return nil
+}
+
+// Set cambia una clave (con puntos para entrar en tablas, como
+// "options.temperature") y registra source como su origen
+func (c *Config) Set(key string, value any, source string) error {
+	parts := strings.Split(key, ".")
+	values := map[string]any{parts[len(parts)-1]: value}
+	for i := len(parts) - 2; i >= 0; i-- {
+		values = map[string]any{parts[i]: values}
+	}
+	if err := c.apply(values, source); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	return nil
+}
+
+// apply combina values sobre c. Las tablas (options, prompts...) se combinan
+// clave por clave; el resto de los valores se reemplaza.
+func (c *Config) apply(values map[string]any, source string) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	// encoding/json reutiliza los elementos de un slice existente; las
+	// listas se reemplazan enteras, así que se vacían antes
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		key, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		if _, ok := values[key]; ok && v.Field(i).Kind() == reflect.Slice {
+			v.Field(i).SetZero()
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("configuración no válida: %w", err)
+	}
+
+	if c.sources == nil {
+		c.sources = make(map[string]string)
+	}
+	for key, v := range values {
+		if table, ok := v.(map[string]any); ok {
+			for sub := range table {
+				c.sources[key+"."+sub] = source
+			}
+			continue
+		}
+		c.sources[key] = source
+	}
+	return nil
+}
+
+// Source devuelve de dónde viene el valor de key (archivo, variable de
+// entorno o flag), o SourceDefault
+func (c *Config) Source(key string) string {
+	if source, ok := c.sources[key]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+// Files devuelve los archivos de configuración leídos
+func (c *Config) Files() []string {
+	return c.files
+}
+
+// Show escribe los valores efectivos con su origen. Los textos largos se
+// abrevian a su primera línea.
+func (c *Config) Show(w io.Writer) {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		field := v.Field(i)
+
+		// Tablas: una línea por clave
+		if field.Kind() == reflect.Map || field.Kind() == reflect.Struct {
+			for _, sub := range subKeys(field) {
+				full := key + "." + sub.name
+				fmt.Fprintf(w, "%-34s %-40s  (%s)\n", full, sub.value, c.Source(full))
+			}
+			continue
+		}
+		fmt.Fprintf(w, "%-34s %-40s  (%s)\n", key, showValue(field.Interface()), c.Source(key))
+	}
+}
+
+type subKey struct{ name, value string }
+
+// subKeys enumera las claves de una tabla en orden. En los structs se
+// omiten los campos sin valor.
+func subKeys(v reflect.Value) []subKey {
+	var out []subKey
+	if v.Kind() == reflect.Map {
+		for _, k := range v.MapKeys() {
+			out = append(out, subKey{k.String(), showValue(v.MapIndex(k).Interface())})
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+		return out
+	}
+
+	data, _ := json.Marshal(v.Interface())
+	var fields map[string]any
+	json.Unmarshal(data, &fields)
+	for name, value := range fields {
+		out = append(out, subKey{name, showValue(value)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out
+}
+
+func showValue(value any) string {
+	if s, ok := value.(string); ok {
+		first, rest, multi := strings.Cut(s, "\n")
+		if multi {
+			return fmt.Sprintf("%q… (%d líneas)", first, strings.Count(rest, "\n")+2)
+		}
+		return fmt.Sprintf("%q", s)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// isolate apunta la configuración del usuario a un directorio temporal y
+// vacía las variables de entorno que lee Load
+func isolate(t *testing.T) (userDir string) {
+	t.Helper()
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	for _, env := range envVars {
+		t.Setenv(env.name, "")
+	}
+	t.Setenv("OLI_ALLOW_OUTSIDE", "")
+	return filepath.Join(xdg, "oli")
+}
+
+func TestLoadLayers(t *testing.T) {
+	userDir := isolate(t)
+	project := t.TempDir()
+	userFile := filepath.Join(userDir, "config.toml")
+	projectFile := filepath.Join(project, ".oli", "config.json")
+
+	writeFile(t, userFile, `
+model = "usuario"
+max_files = 10
+max_depth = 2
+command_allow = ["go test **"]
+
+[options]
+temperature = 0.1
+seed = 1
+`)
+	writeFile(t, projectFile, `{"model": "proyecto", "max_files": 20, "options": {"temperature": 0.5}}`)
+	t.Setenv("OLLAMA_MODEL", "entorno")
+	t.Setenv("OLI_ALLOW_OUTSIDE", "1")
+
+	c, err := Load(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("max_files", 40, "flag --max-files"); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{userFile, projectFile}; !reflect.DeepEqual(c.Files(), want) {
+		t.Errorf("Files() = %v, want %v", c.Files(), want)
+	}
+	tests := []struct {
+		key, source string
+		value       any
+	}{
+		{"model", "env OLLAMA_MODEL", c.Model},
+		{"max_files", "flag --max-files", c.MaxFiles},
+		{"max_depth", userFile, c.MaxDepth},
+		{"command_allow", userFile, c.CommandAllow},
+		{"allow_outside", "env OLI_ALLOW_OUTSIDE", c.AllowOutside},
+		{"options.temperature", projectFile, *c.Options.Temperature},
+		{"options.seed", userFile, *c.Options.Seed},
+		{"index_top_k", SourceDefault, c.IndexTopK},
+	}
+	wantValues := []any{"entorno", 40, 2, []string{"go test **"}, true, 0.5, 1, Default().IndexTopK}
+	for i, tt := range tests {
+		if got := c.Source(tt.key); got != tt.source {
+			t.Errorf("Source(%q) = %q, want %q", tt.key, got, tt.source)
+		}
+		if !reflect.DeepEqual(tt.value, wantValues[i]) {
+			t.Errorf("%s = %#v, want %#v", tt.key, tt.value, wantValues[i])
+		}
+	}
+
+	var out bytes.Buffer
+	c.Show(&out)
+	for _, want := range []string{`"entorno"`, "(env OLLAMA_MODEL)", "(flag --max-files)", "(" + projectFile + ")"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Show() does not contain %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestLoadProjectUserOnlyKeys(t *testing.T) {
+	for _, key := range userOnlyKeys {
+		t.Run(key, func(t *testing.T) {
+			isolate(t)
+			project := t.TempDir()
+			writeFile(t, filepath.Join(project, ".oli", "config.json"), `{"`+key+`": []}`)
+
+			_, err := Load(project)
+			if err == nil || !strings.Contains(err.Error(), "configuración del usuario") {
+				t.Errorf("err = %v, want a user-only error", err)
+			}
+		})
+	}
+
+	// En el archivo del usuario sí se admiten
+	userDir := isolate(t)
+	writeFile(t, filepath.Join(userDir, "config.toml"), "allow_outside = true\nmcp_serve_write = [\"docs/**\"]\n")
+	c, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.AllowOutside || len(c.MCPServeWrite) != 1 {
+		t.Errorf("AllowOutside = %v, MCPServeWrite = %v", c.AllowOutside, c.MCPServeWrite)
+	}
+}
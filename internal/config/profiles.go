@@ -0,0 +1,342 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ollama-cli/internal/llm"
+)
+
+// Profile es un prompt del sistema con sus ajustes: los de [prompts] de la
+// configuración y los archivos prompts/*.md del usuario y del proyecto.
+type Profile struct {
+	Name   string `json:"-"`
+	Source string `json:"-"` // archivo .md, o el origen de prompts.<nombre>
+	System string `json:"-"`
+
+	// Ajustes opcionales del front matter
+	Model     string      `json:"model"`
+	Options   llm.Options `json:"options"`
+	Providers []string    `json:"providers"` // proveedores de contexto; vacío = todos
+	Language  string      `json:"language"`  // idioma de las respuestas
+}
+
+// Profile busca un prompt por nombre. En los prompts .md, las opciones
+// del front matter se aplican sobre las de prompt_options.<nombre>.
+func (c *Config) Profile(name string) (Profile, bool) {
+	if p, ok := c.profiles[name]; ok {
+		p.Options = c.PromptOptions[name].Merge(p.Options)
+		return p, true
+	}
+	system, ok := c.Prompts[name]
+	if !ok {
+		return Profile{}, false
+	}
+	return Profile{
+		Name:    name,
+		Source:  c.Source("prompts." + name),
+		System:  system,
+		Options: c.PromptOptions[name],
+	}, true
+}
+
+// Profiles devuelve todos los prompts ordenados por nombre
+func (c *Config) Profiles() []Profile {
+	names := make(map[string]bool)
+	for name := range c.Prompts {
+		names[name] = true
+	}
+	for name := range c.profiles {
+		names[name] = true
+	}
+
+	out := make([]Profile, 0, len(names))
+	for name := range names {
+		p, _ := c.Profile(name)
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// loadProfiles lee los *.md de cada directorio; los de directorios
+// posteriores reemplazan a los anteriores con el mismo nombre
+func (c *Config) loadProfiles(dirs ...string) error {
+	for _, dir := range dirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*.md"))
+		if err != nil {
+			return err
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			p, err := readProfile(file)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+			if c.profiles == nil {
+				c.profiles = make(map[string]Profile)
+			}
+			c.profiles[p.Name] = p
+		}
+	}
+	return nil
+}
+
+// Snippets devuelve los fragmentos de prompts/snippets/*.md (nombre →
+// texto) que los prompts incluyen con {{template "nombre" .}}
+func (c *Config) Snippets() map[string]string {
+	return c.snippets
+}
+
+// loadSnippets lee los fragmentos de cada directorio; los de directorios
+// posteriores reemplazan a los anteriores con el mismo nombre
+func (c *Config) loadSnippets(dirs ...string) error {
+	for _, dir := range dirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*.md"))
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			if c.snippets == nil {
+				c.snippets = make(map[string]string)
+			}
+			c.snippets[strings.TrimSuffix(filepath.Base(file), ".md")] = strings.TrimSpace(string(data))
+		}
+	}
+	return nil
+}
+
+// readProfile lee un prompt en Markdown: el nombre es el del archivo y el
+// cuerpo es el prompt del sistema. Un front matter opcional entre líneas
+// "---" fija model, options, providers y language.
+func readProfile(file string) (Profile, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	meta, body, err := splitFrontMatter(text)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	var p Profile
+	if len(meta) > 0 {
+		raw, err := json.Marshal(resolveScalars(meta, reflect.TypeOf(p)))
+		if err != nil {
+			return Profile{}, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&p); err != nil {
+			return Profile{}, fmt.Errorf("front matter no válido: %w", err)
+		}
+	}
+	p.Name = strings.TrimSuffix(filepath.Base(file), ".md")
+	p.Source = file
+	p.System = strings.TrimSpace(body)
+	if p.System == "" {
+		return Profile{}, fmt.Errorf("el prompt está vacío")
+	}
+	return p, nil
+}
+
+// splitFrontMatter separa el front matter del cuerpo. Se admite un
+// subconjunto de YAML: "clave: valor", listas "[a, b]" o con "- " y un
+// nivel de tablas anidadas con sangría.
+func splitFrontMatter(text string) (map[string]any, string, error) {
+	lines := strings.Split(text, "\n")
+	if strings.TrimSpace(lines[0]) != "---" {
+		return nil, text, nil
+	}
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return nil, "", fmt.Errorf("front matter sin cerrar (falta una línea ---)")
+	}
+	body := strings.Join(lines[end+1:], "\n")
+
+	meta := make(map[string]any)
+	var parent string // clave cuyo bloque anidado se está leyendo
+	for i, line := range lines[1:end] {
+		lineNo := i + 2
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		indented := line != strings.TrimLeft(line, " \t")
+		if !indented {
+			key, value, ok := strings.Cut(trimmed, ":")
+			if !ok {
+				return nil, "", fmt.Errorf("línea %d: se esperaba \"clave: valor\"", lineNo)
+			}
+			key, value = strings.TrimSpace(key), stripYAMLComment(strings.TrimSpace(value))
+			if value == "" {
+				parent = key
+				continue
+			}
+			parent = ""
+			meta[key] = yamlValue(value)
+			continue
+		}
+
+		if parent == "" {
+			return nil, "", fmt.Errorf("línea %d: sangría inesperada", lineNo)
+		}
+		if item, ok := strings.CutPrefix(trimmed, "- "); ok {
+			list, _ := meta[parent].([]any)
+			meta[parent] = append(list, yamlValue(strings.TrimSpace(item)))
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, "", fmt.Errorf("línea %d: se esperaba \"clave: valor\" o \"- elemento\"", lineNo)
+		}
+		table, _ := meta[parent].(map[string]any)
+		if table == nil {
+			table = make(map[string]any)
+			meta[parent] = table
+		}
+		table[strings.TrimSpace(key)] = yamlValue(strings.TrimSpace(value))
+	}
+	return meta, body, nil
+}
+
+// yamlScalar es un escalar sin comillas del front matter. Su tipo depende
+// del campo al que va (ver resolveScalars): "model: 1.5" es una cadena,
+// "temperature: 1.5" un número.
+type yamlScalar string
+
+// resolveScalars convierte los yamlScalar de v según el tipo t del campo
+// de destino: texto tal cual si es una cadena y, si no, número o booleano
+// cuando lo parecen
+func resolveScalars(v any, t reflect.Type) any {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch v := v.(type) {
+	case yamlScalar:
+		if t != nil && t.Kind() == reflect.String {
+			return string(v)
+		}
+		return scalarValue(string(v))
+	case []any:
+		var elem reflect.Type
+		if t != nil && t.Kind() == reflect.Slice {
+			elem = t.Elem()
+		}
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = resolveScalars(item, elem)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			var field reflect.Type
+			if t != nil && t.Kind() == reflect.Struct {
+				field = jsonField(t, key)
+			}
+			out[key] = resolveScalars(item, field)
+		}
+		return out
+	}
+	return v
+}
+
+// jsonField devuelve el tipo del campo de t con la etiqueta json name, o
+// nil si no hay
+func jsonField(t reflect.Type, name string) reflect.Type {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag == name {
+			return f.Type
+		}
+	}
+	return nil
+}
+
+// scalarValue interpreta un escalar sin comillas: booleano, número o texto
+func scalarValue(s string) any {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+// yamlValue convierte un escalar o una lista en línea: cadenas con o sin
+// comillas (las segundas como yamlScalar, que resolveScalars convierte)
+func yamlValue(s string) any {
+	s = stripYAMLComment(s)
+	if inner, ok := strings.CutPrefix(s, "["); ok && strings.HasSuffix(inner, "]") {
+		list := []any{}
+		for _, item := range strings.Split(strings.TrimSuffix(inner, "]"), ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, yamlValue(item))
+			}
+		}
+		return list
+	}
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		if s[0] == '"' {
+			if unquoted, err := strconv.Unquote(s); err == nil {
+				return unquoted
+			}
+		}
+		return s[1 : len(s)-1]
+	}
+	return yamlScalar(s)
+}
+
+// stripYAMLComment quita de s un comentario final: un "#" al principio o
+// tras un espacio, fuera de comillas
+func stripYAMLComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return strings.TrimSpace(s[:i])
+		}
+	}
+	return s
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ollama-cli/internal/llm"
+)
+
+func writeProfile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name+".md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadProfileFrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	writeProfile(t, dir, "docs", `---
+# Ajustes
+model: 1.5
+language: "español" # sin comillas también vale
+providers: # en orden
+  - filesystem
+  - 'git' # el diff
+options:
+  temperature: 0.3
+  seed: 7 # reproducible
+  stop: [42, "FIN"]
+---
+Escribe documentación.
+`)
+
+	p, err := readProfile(filepath.Join(dir, "docs.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "docs" || p.System != "Escribe documentación." {
+		t.Errorf("Name = %q, System = %q", p.Name, p.System)
+	}
+	if p.Model != "1.5" || p.Language != "español" {
+		t.Errorf("Model = %q, Language = %q", p.Model, p.Language)
+	}
+	if !reflect.DeepEqual(p.Providers, []string{"filesystem", "git"}) {
+		t.Errorf("Providers = %q", p.Providers)
+	}
+	o := p.Options
+	if o.Temperature == nil || *o.Temperature != 0.3 || o.Seed == nil || *o.Seed != 7 {
+		t.Errorf("Options = %+v", o)
+	}
+	if !reflect.DeepEqual(o.Stop, []string{"42", "FIN"}) {
+		t.Errorf("Stop = %q", o.Stop)
+	}
+}
+
+func TestYAMLValue(t *testing.T) {
+	tests := []struct {
+		input string
+		want  any
+	}{
+		{`"llama3" # nota`, "llama3"},
+		{`'llama3' # nota`, "llama3"},
+		{`llama3 # nota`, yamlScalar("llama3")},
+		{`"#uno # dos" # tres`, "#uno # dos"},
+		{`"comilla \" # dentro"`, `comilla " # dentro`},
+		{`C# y F#`, yamlScalar("C# y F#")},
+		{`[a, "b"] # lista`, []any{yamlScalar("a"), "b"}},
+		{`# solo comentario`, yamlScalar("")},
+	}
+	for _, tt := range tests {
+		if got := yamlValue(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("yamlValue(%s) = %#v, want %#v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestReadProfileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unclosed", "---\nmodel: a\nCuerpo\n", "sin cerrar"},
+		{"unknown key", "---\nmodle: a\n---\nCuerpo\n", "front matter no válido"},
+		{"wrong type", "---\noptions:\n  temperature: alta\n---\nCuerpo\n", "front matter no válido"},
+		{"empty body", "---\nmodel: a\n---\n\n", "vacío"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeProfile(t, dir, "p", tt.content)
+			_, err := readProfile(filepath.Join(dir, "p.md"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileMergesPromptOptions(t *testing.T) {
+	user, project := t.TempDir(), t.TempDir()
+	writeProfile(t, user, "code-review", "Revisa en detalle.")
+	writeProfile(t, project, "code-review", "---\noptions:\n  temperature: 0.2\n---\nRevisa el proyecto.")
+
+	c := Default()
+	if err := c.loadProfiles(user, project); err != nil {
+		t.Fatal(err)
+	}
+
+	p, ok := c.Profile("code-review")
+	if !ok {
+		t.Fatal("code-review not found")
+	}
+	if p.System != "Revisa el proyecto." || p.Source != filepath.Join(project, "code-review.md") {
+		t.Errorf("System = %q, Source = %q", p.System, p.Source)
+	}
+	// temperature comes from the front matter, seed from prompt_options
+	want := llm.Options{Temperature: float(0.2), Seed: integer(42)}
+	if !reflect.DeepEqual(p.Options, want) {
+		t.Errorf("Options = %+v, want %+v", p.Options, want)
+	}
+}
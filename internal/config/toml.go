@@ -0,0 +1,542 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseTOML lee el subconjunto de TOML que usa la configuración: tablas,
+// arreglos de tablas, claves con puntos, cadenas (básicas, literales y
+// multilínea), números, booleanos, arreglos y tablas en línea. Las fechas
+// no se admiten. Devuelve los valores como map[string]any, []any, string,
+// int64, float64 y bool, igual que encoding/json.
+func parseTOML(data string) (map[string]any, error) {
+	p := &tomlParser{s: data, defined: make(map[string]bool)}
+	root := make(map[string]any)
+	if err := p.parse(root); err != nil {
+		return nil, fmt.Errorf("línea %d: %w", p.line(), err)
+	}
+	return normalize(root).(map[string]any), nil
+}
+
+type tomlParser struct {
+	s   string
+	pos int
+
+	// defined guarda las tablas con encabezado [tabla], para rechazar los
+	// repetidos. Las claves son las rutas unidas con "\x00".
+	defined map[string]bool
+}
+
+// tableArray es un arreglo de tablas creado con [[encabezado]]. Se
+// distingue de los arreglos estáticos (clave = [...]), que TOML no deja
+// extender, y normalize lo convierte en []any al terminar.
+type tableArray []map[string]any
+
+// normalize reemplaza cada tableArray dentro de v por []any
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = normalize(item)
+		}
+		return v
+	case tableArray:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = normalize(item)
+		}
+		return list
+	}
+	return v
+}
+
+func (p *tomlParser) line() int {
+	return strings.Count(p.s[:min(p.pos, len(p.s))], "\n") + 1
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+// skipSpace salta espacios y tabulaciones
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlank salta espacios, saltos de línea y comentarios
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.s[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		case '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	if i := strings.IndexByte(p.s[p.pos:], '\n'); i >= 0 {
+		p.pos += i
+	} else {
+		p.pos = len(p.s)
+	}
+}
+
+// endOfLine exige que después de un valor o encabezado solo haya un
+// comentario o el fin de la línea
+func (p *tomlParser) endOfLine() error {
+	p.skipSpace()
+	if p.peek() == '#' {
+		p.skipComment()
+	}
+	switch {
+	case p.eof():
+		return nil
+	case strings.HasPrefix(p.s[p.pos:], "\r\n"):
+		p.pos += 2
+		return nil
+	case p.s[p.pos] == '\n':
+		p.pos++
+		return nil
+	}
+	return fmt.Errorf("texto inesperado %q", p.rest())
+}
+
+// rest devuelve lo que queda de la línea actual, para los mensajes de error
+func (p *tomlParser) rest() string {
+	rest := p.s[p.pos:]
+	if i := strings.IndexAny(rest, "\r\n"); i >= 0 {
+		rest = rest[:i]
+	}
+	return rest
+}
+
+func (p *tomlParser) parse(root map[string]any) error {
+	current := root
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil
+		}
+
+		if p.peek() != '[' {
+			keys, err := p.parseKey()
+			if err != nil {
+				return err
+			}
+			p.skipSpace()
+			if p.peek() != '=' {
+				return fmt.Errorf("se esperaba '=' después de %q", strings.Join(keys, "."))
+			}
+			p.pos++
+			p.skipSpace()
+			value, err := p.parseValue()
+			if err != nil {
+				return err
+			}
+			if err := setKey(current, keys, value); err != nil {
+				return err
+			}
+			if err := p.endOfLine(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Encabezado [tabla] o [[arreglo de tablas]]
+		array := strings.HasPrefix(p.s[p.pos:], "[[")
+		if array {
+			p.pos += 2
+		} else {
+			p.pos++
+		}
+		p.skipSpace()
+		keys, err := p.parseKey()
+		if err != nil {
+			return err
+		}
+		p.skipSpace()
+		closing := "]"
+		if array {
+			closing = "]]"
+		}
+		if !strings.HasPrefix(p.s[p.pos:], closing) {
+			return fmt.Errorf("se esperaba %q al final del encabezado", closing)
+		}
+		p.pos += len(closing)
+
+		parent, err := table(root, keys[:len(keys)-1])
+		if err != nil {
+			return err
+		}
+		last := keys[len(keys)-1]
+		path := strings.Join(keys, "\x00")
+		if array {
+			list, _ := parent[last].(tableArray)
+			if _, exists := parent[last]; exists && list == nil {
+				return fmt.Errorf("%q no es un arreglo de tablas", strings.Join(keys, "."))
+			}
+			current = make(map[string]any)
+			parent[last] = append(list, current)
+			// Cada elemento nuevo puede volver a definir sus subtablas
+			for k := range p.defined {
+				if strings.HasPrefix(k, path+"\x00") {
+					delete(p.defined, k)
+				}
+			}
+		} else {
+			if p.defined[path] {
+				return fmt.Errorf("tabla repetida [%s]", strings.Join(keys, "."))
+			}
+			p.defined[path] = true
+			if current, err = table(parent, []string{last}); err != nil {
+				return err
+			}
+		}
+		if err := p.endOfLine(); err != nil {
+			return err
+		}
+	}
+}
+
+// table devuelve la tabla en path dentro de m, creándola si no existe. En
+// un arreglo de tablas se usa el último elemento, como indica TOML; un
+// arreglo estático (clave = [...]) no se puede extender.
+func table(m map[string]any, path []string) (map[string]any, error) {
+	for i, key := range path {
+		switch v := m[key].(type) {
+		case nil:
+			next := make(map[string]any)
+			m[key] = next
+			m = next
+		case map[string]any:
+			m = v
+		case tableArray:
+			m = v[len(v)-1]
+		case []any:
+			return nil, fmt.Errorf("no se puede extender el arreglo estático %q", strings.Join(path[:i+1], "."))
+		default:
+			return nil, fmt.Errorf("%q no es una tabla", strings.Join(path[:i+1], "."))
+		}
+	}
+	return m, nil
+}
+
+func setKey(m map[string]any, keys []string, value any) error {
+	parent, err := table(m, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, exists := parent[last]; exists {
+		return fmt.Errorf("clave repetida %q", strings.Join(keys, "."))
+	}
+	parent[last] = value
+	return nil
+}
+
+// parseKey lee una clave simple, entre comillas o con puntos (a."b".c)
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace()
+		var key string
+		switch c := p.peek(); {
+		case c == '"':
+			s, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		case c == '\'':
+			s, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.s[p.pos]) {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, fmt.Errorf("clave no válida en %q", p.rest())
+			}
+			key = p.s[start:p.pos]
+		}
+		keys = append(keys, key)
+
+		p.skipSpace()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) parseValue() (any, error) {
+	rest := p.s[p.pos:]
+	switch {
+	case strings.HasPrefix(rest, `"""`):
+		return p.parseMultilineString(`"""`, true)
+	case strings.HasPrefix(rest, "'''"):
+		return p.parseMultilineString("'''", false)
+	case strings.HasPrefix(rest, `"`):
+		return p.parseBasicString()
+	case strings.HasPrefix(rest, "'"):
+		return p.parseLiteralString()
+	case strings.HasPrefix(rest, "["):
+		return p.parseArray()
+	case strings.HasPrefix(rest, "{"):
+		return p.parseInlineTable()
+	case strings.HasPrefix(rest, "true"):
+		p.pos += 4
+		return true, nil
+	case strings.HasPrefix(rest, "false"):
+		p.pos += 5
+		return false, nil
+	}
+
+	start := p.pos
+	for !p.eof() && strings.IndexByte("0123456789abcdefinoxABCDEF_+-.", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	token := strings.ReplaceAll(p.s[start:p.pos], "_", "")
+	if token == "" {
+		return nil, fmt.Errorf("valor no válido en %q", p.rest())
+	}
+	return parseNumber(token)
+}
+
+// parseNumber lee un entero o un float de TOML. Solo 0x, 0o y 0b cambian
+// la base: a diferencia de strconv con base 0, "010" no es octal sino un
+// error, como en TOML.
+func parseNumber(token string) (any, error) {
+	for prefix, base := range map[string]int{"0x": 16, "0o": 8, "0b": 2} {
+		if digits, ok := strings.CutPrefix(token, prefix); ok {
+			n, err := strconv.ParseInt(digits, base, 64)
+			if err != nil {
+				return nil, fmt.Errorf("número no válido %q", token)
+			}
+			return n, nil
+		}
+	}
+
+	digits := strings.TrimLeft(token, "+-")
+	if len(digits) > 1 && digits[0] == '0' && digits[1] >= '0' && digits[1] <= '9' {
+		return nil, fmt.Errorf("número no válido %q (no se admiten ceros a la izquierda)", token)
+	}
+	if n, err := strconv.ParseInt(token, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(token, 64); err == nil && !strings.ContainsAny(digits, "xX") {
+		return f, nil
+	}
+	return nil, fmt.Errorf("valor no válido %q (las fechas no se admiten)", token)
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++ // "
+	var sb strings.Builder
+	for {
+		if p.eof() || p.s[p.pos] == '\n' {
+			return "", fmt.Errorf("cadena sin cerrar")
+		}
+		c := p.s[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return sb.String(), nil
+		case '\\':
+			if err := p.parseEscape(&sb); err != nil {
+				return "", err
+			}
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++ // '
+	end := strings.IndexAny(p.s[p.pos:], "'\n")
+	if end < 0 || p.s[p.pos+end] != '\'' {
+		return "", fmt.Errorf("cadena sin cerrar")
+	}
+	s := p.s[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+// parseMultilineString lee una cadena entre tres comillas dobles (con
+// escapes) o tres simples (literal).
+// Se omite el salto de línea que sigue a la apertura.
+func (p *tomlParser) parseMultilineString(delim string, escapes bool) (string, error) {
+	p.pos += len(delim)
+	if strings.HasPrefix(p.s[p.pos:], "\r\n") {
+		p.pos += 2
+	} else if p.peek() == '\n' {
+		p.pos++
+	}
+
+	var sb strings.Builder
+	for {
+		if p.eof() {
+			return "", fmt.Errorf("cadena multilínea sin cerrar")
+		}
+		if strings.HasPrefix(p.s[p.pos:], delim) {
+			// Hasta dos comillas pegadas al cierre forman parte del texto
+			p.pos += len(delim)
+			for i := 0; i < 2 && p.peek() == delim[0]; i++ {
+				sb.WriteByte(delim[0])
+				p.pos++
+			}
+			return sb.String(), nil
+		}
+		c := p.s[p.pos]
+		if escapes && c == '\\' {
+			// "\" al final de la línea une la siguiente sin espacios iniciales
+			after := strings.TrimLeft(p.s[p.pos+1:], " \t")
+			if strings.HasPrefix(after, "\n") || strings.HasPrefix(after, "\r\n") {
+				p.pos = len(p.s) - len(strings.TrimLeft(after, " \t\r\n"))
+				continue
+			}
+			if err := p.parseEscape(&sb); err != nil {
+				return "", err
+			}
+			continue
+		}
+		sb.WriteByte(c)
+		p.pos++
+	}
+}
+
+func (p *tomlParser) parseEscape(sb *strings.Builder) error {
+	p.pos++ // \
+	if p.eof() {
+		return fmt.Errorf("escape incompleto")
+	}
+	c := p.s[p.pos]
+	p.pos++
+	switch c {
+	case 'n':
+		sb.WriteByte('\n')
+	case 't':
+		sb.WriteByte('\t')
+	case 'r':
+		sb.WriteByte('\r')
+	case 'b':
+		sb.WriteByte('\b')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'e':
+		sb.WriteByte(0x1b)
+	case '"', '\\':
+		sb.WriteByte(c)
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.s) {
+			return fmt.Errorf("escape \\%c incompleto", c)
+		}
+		code, err := strconv.ParseUint(p.s[p.pos:p.pos+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return fmt.Errorf("escape \\%c%s no válido", c, p.s[p.pos:p.pos+size])
+		}
+		sb.WriteRune(rune(code))
+		p.pos += size
+	default:
+		return fmt.Errorf("escape \\%c no válido", c)
+	}
+	return nil
+}
+
+func (p *tomlParser) parseArray() ([]any, error) {
+	p.pos++ // [
+	list := []any{}
+	for {
+		p.skipBlank()
+		if p.peek() == ']' {
+			p.pos++
+			return list, nil
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return list, nil
+		default:
+			return nil, fmt.Errorf("se esperaba ',' o ']' en el arreglo")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (map[string]any, error) {
+	p.pos++ // {
+	m := make(map[string]any)
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.pos++
+		return m, nil
+	}
+	for {
+		keys, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.peek() != '=' {
+			return nil, fmt.Errorf("se esperaba '=' después de %q", strings.Join(keys, "."))
+		}
+		p.pos++
+		p.skipSpace()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := setKey(m, keys, value); err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return m, nil
+		default:
+			return nil, fmt.Errorf("se esperaba ',' o '}' en la tabla en línea")
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	input := `# configuración
+model = "llama3"
+temperature = 0.5
+num_ctx = 8_192
+stream = true
+"clave con espacios" = 'C:\ruta\literal'
+escapes = "tab\tcomilla\" é\u00e9"
+providers = ["filesystem", "git",] # coma final
+nested = [[1, 2], ["a"]]
+env = { HOME = "/home/oli", depth.max = 3 }
+multi = """
+línea uno \
+   sigue
+línea dos"""
+literal = '''
+sin \escapes'''
+
+[prompt_options.code-review]
+temperature = 0.1
+
+[[mcp_servers]]
+name = "fs"
+args = [
+  "--root",
+  ".",
+]
+
+[mcp_servers.env]
+TOKEN = "a"
+
+[[mcp_servers]]
+name = "git"
+
+[mcp_servers.env]
+TOKEN = "b"
+`
+	got, err := parseTOML(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"model":              "llama3",
+		"temperature":        0.5,
+		"num_ctx":            int64(8192),
+		"stream":             true,
+		"clave con espacios": `C:\ruta\literal`,
+		"escapes":            "tab\tcomilla\" éé",
+		"providers":          []any{"filesystem", "git"},
+		"nested":             []any{[]any{int64(1), int64(2)}, []any{"a"}},
+		"env":                map[string]any{"HOME": "/home/oli", "depth": map[string]any{"max": int64(3)}},
+		"multi":              "línea uno sigue\nlínea dos",
+		"literal":            `sin \escapes`,
+		"prompt_options": map[string]any{
+			"code-review": map[string]any{"temperature": 0.1},
+		},
+		"mcp_servers": []any{
+			map[string]any{"name": "fs", "args": []any{"--root", "."}, "env": map[string]any{"TOKEN": "a"}},
+			map[string]any{"name": "git", "env": map[string]any{"TOKEN": "b"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTOML() =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestParseTOMLNumbers(t *testing.T) {
+	tests := []struct {
+		input string
+		want  any
+	}{
+		{"0", int64(0)},
+		{"-0", int64(0)},
+		{"+17", int64(17)},
+		{"10", int64(10)},
+		{"1_000", int64(1000)},
+		{"0x1F", int64(31)},
+		{"0xdead_beef", int64(0xdeadbeef)},
+		{"0o17", int64(15)},
+		{"0b101", int64(5)},
+		{"0.5", 0.5},
+		{"-1.5e3", -1500.0},
+		{"0e0", 0.0},
+		{"inf", math.Inf(1)},
+	}
+	for _, tt := range tests {
+		got, err := parseTOML("a = " + tt.input + "\n")
+		if err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+		if got["a"] != tt.want {
+			t.Errorf("%s = %#v, want %#v", tt.input, got["a"], tt.want)
+		}
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"extend empty static array", "mcp_servers = []\n[mcp_servers.x]\n", "arreglo estático"},
+		{"extend static array", "servers = [{ name = \"a\" }]\n[servers.env]\n", "arreglo estático"},
+		{"array of tables over static array", "servers = []\n[[servers]]\n", "no es un arreglo de tablas"},
+		{"duplicate table", "[a]\nx = 1\n[b]\n[a]\ny = 2\n", "tabla repetida [a]"},
+		{"duplicate subtable in array element", "[[s]]\n[s.env]\n[s.env]\n", "tabla repetida [s.env]"},
+		{"duplicate key", "a = 1\na = 2\n", "clave repetida"},
+		{"unclosed string", "a = \"abc\n", "cadena sin cerrar"},
+		{"unclosed multiline", "a = \"\"\"abc\n", "multilínea sin cerrar"},
+		{"trailing text", "a = 1 b\n", "texto inesperado"},
+		{"date", "a = 2024-01-01\n", "fechas"},
+		{"leading zero", "a = 010\n", "ceros a la izquierda"},
+		{"signed leading zero", "a = -007\n", "ceros a la izquierda"},
+		{"leading zero float", "a = 01.5\n", "ceros a la izquierda"},
+		{"prefix without digits", "a = 0x\n", "número no válido"},
+		{"bad binary digit", "a = 0b102\n", "número no válido"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTOML(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
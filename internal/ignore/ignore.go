@@ -0,0 +1,200 @@
+// Package ignore implements gitignore-style path matching.
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// rule is one compiled pattern line.
+type rule struct {
+	base    string // Directory of the file that defined it, relative to the root; "" for the root
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Matcher decides whether paths are ignored. Rules are evaluated in the
+// order they were added and the last one that matches wins, so files from
+// deeper directories must be added after their parents.
+type Matcher struct {
+	rules []rule
+}
+
+// New returns an empty Matcher.
+func New() *Matcher {
+	return &Matcher{}
+}
+
+// Add compiles gitignore lines defined in directory base (slash-separated,
+// relative to the matcher root, "" for the root itself).
+func (m *Matcher) Add(base string, lines []string) {
+	base = strings.Trim(base, "/")
+	if base == "." {
+		base = ""
+	}
+	for _, line := range lines {
+		if r, ok := compile(line); ok {
+			r.base = base
+			m.rules = append(m.rules, r)
+		}
+	}
+}
+
+// AddFile reads patterns from file, which lives in directory base.
+// A missing file is not an error.
+func (m *Matcher) AddFile(file, base string) error {
+	f, err := os.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	m.Add(base, lines)
+	return nil
+}
+
+// Match reports whether rel (slash-separated, relative to the matcher root)
+// is ignored. isDir tells whether rel is a directory, for patterns ending
+// in "/". Like git, it does not look at parent directories: callers walking
+// a tree skip the contents of ignored directories themselves.
+func (m *Matcher) Match(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if r.base != "" {
+			var ok bool
+			if sub, ok = strings.CutPrefix(rel, r.base+"/"); !ok {
+				continue
+			}
+		}
+		if r.re.MatchString(sub) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// compile turns a gitignore line into a rule. Blank lines and comments
+// yield ok == false.
+func compile(line string) (r rule, ok bool) {
+	line = trimTrailingSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+
+	// A slash anywhere but the end anchors the pattern to its directory;
+	// otherwise it matches a name at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+	sb.WriteString(translate(line))
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return rule{}, false
+	}
+	r.re = re
+	return r, true
+}
+
+// translate converts glob syntax to a regular expression body.
+func translate(pattern string) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/") && (i == 0 || pattern[i-1] == '/'):
+			// Zero or more leading directories
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**") && i > 0 && pattern[i-1] == '/' && i+2 == len(pattern):
+			// Everything inside the directory
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			// A "**" in other positions behaves like "*"
+			for i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+			}
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// trimTrailingSpace removes unescaped trailing spaces.
+func trimTrailingSpace(line string) string {
+	line = strings.TrimRight(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// Join builds a matcher-relative path from a directory and a name.
+func Join(dir, name string) string {
+	if dir == "" || dir == "." {
+		return name
+	}
+	return path.Join(dir, name)
+}
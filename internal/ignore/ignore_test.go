@@ -0,0 +1,157 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type matchCase struct {
+	rel   string
+	isDir bool
+	want  bool
+}
+
+func check(t *testing.T, m *Matcher, cases []matchCase) {
+	t.Helper()
+	for _, c := range cases {
+		if got := m.Match(c.rel, c.isDir); got != c.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", c.rel, c.isDir, got, c.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		cases    []matchCase
+	}{
+		{
+			"unanchored name",
+			[]string{"*.log", "build"},
+			[]matchCase{
+				{"app.log", false, true},
+				{"logs/deep/app.log", false, true},
+				{"app.log.txt", false, false},
+				{"build", true, true},
+				{"src/build", false, true},
+				{"builder", false, false},
+			},
+		},
+		{
+			"anchored with leading slash",
+			[]string{"/vendor", "/cmd/tmp.go"},
+			[]matchCase{
+				{"vendor", true, true},
+				{"src/vendor", true, false},
+				{"cmd/tmp.go", false, true},
+				{"x/cmd/tmp.go", false, false},
+			},
+		},
+		{
+			"anchored by an inner slash",
+			[]string{"docs/*.md"},
+			[]matchCase{
+				{"docs/a.md", false, true},
+				{"docs/sub/a.md", false, false},
+				{"x/docs/a.md", false, false},
+			},
+		},
+		{
+			"directory only",
+			[]string{"out/"},
+			[]matchCase{
+				{"out", true, true},
+				{"src/out", true, true},
+				{"out", false, false},
+			},
+		},
+		{
+			"negation, last match wins",
+			[]string{"*.md", "!README.md", "docs/README.md"},
+			[]matchCase{
+				{"notes.md", false, true},
+				{"README.md", false, false},
+				{"sub/README.md", false, false},
+				{"docs/README.md", false, true},
+			},
+		},
+		{
+			"double star",
+			[]string{"**/testdata", "logs/**", "a/**/z.txt", "x**y"},
+			[]matchCase{
+				{"testdata", true, true},
+				{"pkg/deep/testdata", true, true},
+				{"logs/a/b.txt", false, true},
+				{"logs", true, false},
+				{"a/z.txt", false, true},
+				{"a/b/c/z.txt", false, true},
+				{"b/z.txt", false, false},
+				{"xay", false, true},
+				{"xa/y", false, false},
+			},
+		},
+		{
+			"character classes, escapes and comments",
+			[]string{"# comment", "", "file[0-9].txt", "tmp[!a]", `\#hash`, `\!bang`, "trailing   "},
+			[]matchCase{
+				{"file3.txt", false, true},
+				{"filex.txt", false, false},
+				{"tmpb", false, true},
+				{"tmpa", false, false},
+				{"#hash", false, true},
+				{"!bang", false, true},
+				{"trailing", false, true},
+				{"# comment", false, false},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New()
+			m.Add("", tt.patterns)
+			check(t, m, tt.cases)
+		})
+	}
+}
+
+func TestNestedFiles(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "web", "static"), 0755)
+	os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.tmp\nnode_modules/\n"), 0644)
+	os.WriteFile(filepath.Join(root, "web", ".gitignore"), []byte("/dist\n!keep.tmp\n"), 0644)
+
+	m := New()
+	for _, dir := range []string{"", "web", "web/static"} {
+		if err := m.AddFile(filepath.Join(root, filepath.FromSlash(dir), ".gitignore"), dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check(t, m, []matchCase{
+		{"a.tmp", false, true},
+		{"web/a.tmp", false, true},
+		{"node_modules", true, true},
+		{"web/node_modules", true, true},
+		// Rules of web/.gitignore are relative to web/
+		{"web/dist", true, true},
+		{"dist", true, false},
+		{"web/static/dist", true, false},
+		{"web/keep.tmp", false, false},
+		{"web/static/keep.tmp", false, false},
+		{"keep.tmp", false, true},
+	})
+}
+
+func TestJoin(t *testing.T) {
+	for _, tt := range []struct{ dir, name, want string }{
+		{"", "a", "a"},
+		{".", "a", "a"},
+		{"web", "a", "web/a"},
+	} {
+		if got := Join(tt.dir, tt.name); got != tt.want {
+			t.Errorf("Join(%q, %q) = %q, want %q", tt.dir, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package index
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ollama-cli/internal/llm"
+)
+
+// Dir is the per-project directory where oli keeps its data.
+const Dir = ".oli"
+
+const fileName = "index.json"
+
+// gitignore keeps the index out of version control: .oli/ also holds the
+// project's config.toml and prompts/, which are meant to be committed.
+const gitignore = `# Generado por oli: el índice semántico no se versiona
+index.json
+index.json.*.tmp
+`
+
+// Chunking parameters: windows of chunkLines lines, each starting
+// chunkLines-chunkOverlap lines after the previous one.
+const (
+	chunkLines   = 40
+	chunkOverlap = 8
+	maxChunkSize = 4000 // Bytes sent to the embedding model per chunk
+	embedBatch   = 16   // Chunks per /api/embed request
+)
+
+// ErrNotFound is returned by Load when the project has no index.
+var ErrNotFound = errors.New("index not found")
+
+// Chunk is a range of lines of a file with its embedding.
+type Chunk struct {
+	Path   string    `json:"path"` // Relative to the project root, slash-separated
+	Start  int       `json:"start"`
+	End    int       `json:"end"`
+	Text   string    `json:"text"`
+	Vector []float32 `json:"vector"`
+}
+
+// File records the state of an indexed file to detect changes.
+type File struct {
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+	Hash    string    `json:"hash"`
+}
+
+// Index is the on-disk embeddings index of a project.
+type Index struct {
+	Model   string          `json:"model"`
+	Updated time.Time       `json:"updated"`
+	Files   map[string]File `json:"files"`
+	Chunks  []Chunk         `json:"chunks"`
+}
+
+// Stats summarises an Update.
+type Stats struct {
+	Added, Changed, Removed, Unchanged int
+}
+
+// Result is a chunk returned by Search.
+type Result struct {
+	Chunk
+	Score float64
+}
+
+// Path returns the index file of the project at root.
+func Path(root string) string {
+	return filepath.Join(root, Dir, fileName)
+}
+
+// Load reads the index of the project at root.
+func Load(root string) (*Index, error) {
+	data, err := os.ReadFile(Path(root))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+	var ix Index
+	if err := json.Unmarshal(data, &ix); err != nil {
+		return nil, fmt.Errorf("parse index: %w", err)
+	}
+	if ix.Files == nil {
+		ix.Files = make(map[string]File)
+	}
+	return &ix, nil
+}
+
+// New returns an empty index for model.
+func New(model string) *Index {
+	return &Index{Model: model, Files: make(map[string]File)}
+}
+
+// Save writes the index under root, replacing the previous one atomically.
+func (ix *Index) Save(root string) error {
+	dir := filepath.Join(root, Dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create index dir: %w", err)
+	}
+	if err := writeGitignore(dir); err != nil {
+		return fmt.Errorf("write %s/.gitignore: %w", Dir, err)
+	}
+
+	data, err := json.Marshal(ix)
+	if err != nil {
+		return fmt.Errorf("marshal index: %w", err)
+	}
+
+	// Write to a temp file first so a crash never leaves a truncated index
+	tmp, err := os.CreateTemp(dir, fileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write index: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), Path(root)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write index: %w", err)
+	}
+	return nil
+}
+
+// writeGitignore creates dir/.gitignore on the first save. An existing one
+// is left alone: the user may have chosen otherwise.
+func writeGitignore(dir string) error {
+	f, err := os.OpenFile(filepath.Join(dir, ".gitignore"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(gitignore); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Clear removes the index of the project at root.
+func Clear(root string) error {
+	err := os.Remove(Path(root))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Update brings the index in line with files (paths relative to root).
+// Files whose modification time and size are unchanged are skipped without
+// reading them; the rest are hashed and only re-embedded if their content
+// changed. Files no longer listed are dropped. progress, if not nil, is
+// called before embedding each file.
+//
+// Files are recorded one at a time, so on error the index holds everything
+// embedded so far and can be saved to resume later.
+func (ix *Index) Update(ctx context.Context, root string, files []string, embedder llm.Embedder, progress func(path string)) (Stats, error) {
+	var stats Stats
+
+	listed := make(map[string]bool, len(files))
+	for _, rel := range files {
+		rel = filepath.ToSlash(rel)
+		listed[rel] = true
+
+		info, err := os.Stat(filepath.Join(root, rel))
+		if err != nil {
+			continue
+		}
+
+		old, known := ix.Files[rel]
+		if known && old.ModTime.Equal(info.ModTime()) && old.Size == info.Size() {
+			stats.Unchanged++
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(root, rel))
+		if err != nil {
+			continue
+		}
+		entry := File{ModTime: info.ModTime(), Size: info.Size(), Hash: hash(data)}
+		if known && old.Hash == entry.Hash {
+			ix.Files[rel] = entry
+			stats.Unchanged++
+			continue
+		}
+
+		if progress != nil {
+			progress(rel)
+		}
+		chunks, err := embedChunks(ctx, embedder, ix.Model, split(rel, string(data)))
+		if err != nil {
+			return stats, fmt.Errorf("%s: %w", rel, err)
+		}
+
+		ix.drop(rel)
+		ix.Chunks = append(ix.Chunks, chunks...)
+		ix.Files[rel] = entry
+		if known {
+			stats.Changed++
+		} else {
+			stats.Added++
+		}
+	}
+
+	for rel := range ix.Files {
+		if !listed[rel] {
+			ix.drop(rel)
+			delete(ix.Files, rel)
+			stats.Removed++
+		}
+	}
+
+	ix.Updated = time.Now()
+	return stats, nil
+}
+
+// Stale returns how many of files (relative to root) changed or are missing
+// from the index, and how many indexed files no longer exist.
+func (ix *Index) Stale(root string, files []string) (changed, removed int) {
+	listed := make(map[string]bool, len(files))
+	for _, rel := range files {
+		rel = filepath.ToSlash(rel)
+		listed[rel] = true
+
+		entry, ok := ix.Files[rel]
+		info, err := os.Stat(filepath.Join(root, rel))
+		if !ok || err != nil || !entry.ModTime.Equal(info.ModTime()) || entry.Size != info.Size() {
+			changed++
+		}
+	}
+	for rel := range ix.Files {
+		if !listed[rel] {
+			removed++
+		}
+	}
+	return changed, removed
+}
+
+// Search returns the k chunks most similar to vector by cosine similarity.
+func (ix *Index) Search(vector []float32, k int) []Result {
+	results := make([]Result, 0, len(ix.Chunks))
+	for _, c := range ix.Chunks {
+		results = append(results, Result{Chunk: c, Score: cosine(vector, c.Vector)})
+	}
+	sort.SliceStable(results, func(a, b int) bool {
+		return results[a].Score > results[b].Score
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+func (ix *Index) drop(rel string) {
+	kept := ix.Chunks[:0]
+	for _, c := range ix.Chunks {
+		if c.Path != rel {
+			kept = append(kept, c)
+		}
+	}
+	ix.Chunks = kept
+}
+
+// split cuts content into overlapping windows of lines.
+func split(rel, content string) []Chunk {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if len(lines) == 1 && strings.TrimSpace(lines[0]) == "" {
+		return nil
+	}
+
+	var chunks []Chunk
+	for start := 0; start < len(lines); start += chunkLines - chunkOverlap {
+		end := min(start+chunkLines, len(lines))
+		text := strings.Join(lines[start:end], "\n")
+		if strings.TrimSpace(text) != "" {
+			chunks = append(chunks, Chunk{Path: rel, Start: start + 1, End: end, Text: text})
+		}
+		if end == len(lines) {
+			break
+		}
+	}
+	return chunks
+}
+
+func embedChunks(ctx context.Context, embedder llm.Embedder, model string, chunks []Chunk) ([]Chunk, error) {
+	for i := 0; i < len(chunks); i += embedBatch {
+		batch := chunks[i:min(i+embedBatch, len(chunks))]
+		input := make([]string, len(batch))
+		for j, c := range batch {
+			// The path gives the model a hint of what the code is about
+			input[j] = truncate(c.Path+"\n"+c.Text, maxChunkSize)
+		}
+
+		vectors, err := embedder.Embed(ctx, model, input)
+		if err != nil {
+			return nil, err
+		}
+		for j := range batch {
+			batch[j].Vector = vectors[j]
+		}
+	}
+	return chunks, nil
+}
+
+// truncate cuts text to at most n bytes, at the last line break that fits
+// or, for a single long line, at a character boundary.
+func truncate(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	cut := text[:n]
+	if i := strings.LastIndexByte(cut, '\n'); i > 0 {
+		return cut[:i]
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n]
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,202 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// fakeEmbedder returns a vector derived from the text length and records
+// every input it receives.
+type fakeEmbedder struct {
+	inputs []string
+}
+
+func (f *fakeEmbedder) Embed(ctx context.Context, model string, input []string) ([][]float32, error) {
+	f.inputs = append(f.inputs, input...)
+	vectors := make([][]float32, len(input))
+	for i, text := range input {
+		vectors[i] = []float32{float32(len(text)), 1}
+	}
+	return vectors, nil
+}
+
+func (f *fakeEmbedder) embedded() []string {
+	var paths []string
+	for _, in := range f.inputs {
+		path, _, _ := strings.Cut(in, "\n")
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestSaveIgnoresIndexInGit(t *testing.T) {
+	root := t.TempDir()
+	if err := New("m").Save(root); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, Dir, ".gitignore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != gitignore {
+		t.Errorf(".gitignore = %q", data)
+	}
+
+	// A .gitignore written by the user is kept
+	os.WriteFile(filepath.Join(root, Dir, ".gitignore"), []byte("custom\n"), 0644)
+	if err := New("m").Save(root); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(filepath.Join(root, Dir, ".gitignore"))
+	if string(data) != "custom\n" {
+		t.Errorf(".gitignore overwritten: %q", data)
+	}
+
+	if _, err := Load(root); err != nil {
+		t.Errorf("Load() = %v", err)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 100; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	chunks := split("a.go", strings.Join(lines, "\n")+"\n")
+
+	var ranges [][2]int
+	for _, c := range chunks {
+		ranges = append(ranges, [2]int{c.Start, c.End})
+	}
+	// Windows of 40 lines, each starting 32 after the previous one
+	want := [][2]int{{1, 40}, {33, 72}, {65, 100}}
+	if !reflect.DeepEqual(ranges, want) {
+		t.Errorf("ranges = %v, want %v", ranges, want)
+	}
+	if chunks[1].Text != strings.Join(lines[32:72], "\n") {
+		t.Errorf("second chunk = %q", chunks[1].Text)
+	}
+
+	if got := split("empty.go", "\n\n"); got != nil {
+		t.Errorf("blank file: %v", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		text string
+		n    int
+		want string
+	}{
+		{"corto", 10, "corto"},
+		{"uno\ndos\ntres", 9, "uno\ndos"},
+		{"ñññ", 4, "ññ"},
+		{"añb", 2, "a"},
+	}
+	for _, tt := range tests {
+		got := truncate(tt.text, tt.n)
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.text, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestEmbedLongLineKeepsUTF8(t *testing.T) {
+	embedder := &fakeEmbedder{}
+	chunks := []Chunk{{Path: "a.md", Text: strings.Repeat("ñ", maxChunkSize)}}
+	if _, err := embedChunks(context.Background(), embedder, "m", chunks); err != nil {
+		t.Fatal(err)
+	}
+	if in := embedder.inputs[0]; len(in) > maxChunkSize || !utf8.ValidString(in) {
+		t.Errorf("input has %d bytes, valid UTF-8: %v", len(in), utf8.ValidString(in))
+	}
+}
+
+func TestUpdateIsIncremental(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// A distinct mtime, so the change is noticed even within a second
+		later := time.Now().Add(time.Duration(len(content)) * time.Second)
+		os.Chtimes(path, later, later)
+	}
+	write("a.go", "package a")
+	write("b.go", "package b")
+	write("c.go", "package c")
+
+	ix := New("m")
+	embedder := &fakeEmbedder{}
+	stats, err := ix.Update(context.Background(), root, []string{"a.go", "b.go", "c.go"}, embedder, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (Stats{Added: 3}) || len(ix.Chunks) != 3 {
+		t.Fatalf("first update: %+v, %d chunks", stats, len(ix.Chunks))
+	}
+
+	// a.go changes, b.go is touched with the same content, c.go is removed
+	embedder.inputs = nil
+	write("a.go", "package a // v2")
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(root, "b.go"), later, later)
+
+	var progress []string
+	stats, err = ix.Update(context.Background(), root, []string{"a.go", "b.go"}, embedder, func(rel string) {
+		progress = append(progress, rel)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (Stats{Changed: 1, Removed: 1, Unchanged: 1}) {
+		t.Errorf("second update: %+v", stats)
+	}
+	if !reflect.DeepEqual(embedder.embedded(), []string{"a.go"}) || !reflect.DeepEqual(progress, []string{"a.go"}) {
+		t.Errorf("embedded %q, progress %q; want only a.go", embedder.embedded(), progress)
+	}
+	var paths []string
+	for _, c := range ix.Chunks {
+		paths = append(paths, c.Path+": "+c.Text)
+	}
+	if want := []string{"b.go: package b", "a.go: package a // v2"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("chunks = %q, want %q", paths, want)
+	}
+
+	if changed, removed := ix.Stale(root, []string{"a.go", "b.go"}); changed != 0 || removed != 0 {
+		t.Errorf("Stale() = %d, %d after update", changed, removed)
+	}
+	if changed, removed := ix.Stale(root, []string{"a.go", "d.go"}); changed != 1 || removed != 1 {
+		t.Errorf("Stale() = %d, %d, want 1, 1", changed, removed)
+	}
+}
+
+func TestSearchTopK(t *testing.T) {
+	ix := New("m")
+	ix.Chunks = []Chunk{
+		{Path: "orthogonal", Vector: []float32{0, 1}},
+		{Path: "same", Vector: []float32{2, 0}},
+		{Path: "close", Vector: []float32{1, 0.2}},
+		{Path: "opposite", Vector: []float32{-1, 0}},
+		{Path: "mismatched", Vector: []float32{1}},
+	}
+
+	var got []string
+	for _, r := range ix.Search([]float32{1, 0}, 3) {
+		got = append(got, r.Path)
+	}
+	if want := []string{"same", "close", "orthogonal"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() = %q, want %q", got, want)
+	}
+	if all := ix.Search([]float32{1, 0}, 10); len(all) != 5 || all[4].Path != "opposite" {
+		t.Errorf("Search(k=10) = %+v", all)
+	}
+}
@@ -0,0 +1,200 @@
+package journal
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ollama-cli/internal/atomicfile"
+)
+
+// Entry records the state of a file just before oli wrote it.
+type Entry struct {
+	ID        string      `json:"id"`
+	Path      string      `json:"path"` // Absolute path
+	Existed   bool        `json:"existed"`
+	Original  []byte      `json:"original,omitempty"`
+	Mode      fs.FileMode `json:"mode,omitempty"`
+	Written   string      `json:"written,omitempty"` // SHA-256 of what oli wrote
+	Time      time.Time   `json:"time"`
+	SessionID string      `json:"session_id,omitempty"`
+}
+
+// ErrChanged is returned by Undo when the file was edited after oli wrote
+// it, so restoring it would lose those edits.
+var ErrChanged = errors.New("file changed since oli wrote it")
+
+// Journal stores one JSON record per write in a directory, so every file
+// change made by oli can be undone.
+type Journal struct {
+	dir       string
+	sessionID string
+}
+
+// DefaultDir returns $XDG_DATA_HOME/oli/journal, falling back to
+// ~/.local/share/oli/journal.
+func DefaultDir() (string, error) {
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		return filepath.Join(xdg, "oli", "journal"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("locate home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", "oli", "journal"), nil
+}
+
+func New(dir string) *Journal {
+	return &Journal{dir: dir}
+}
+
+// SetSession tags subsequent records with an interactive session ID.
+func (j *Journal) SetSession(id string) {
+	j.sessionID = id
+}
+
+// Record saves the current state of path before it is overwritten with
+// content. path must be absolute.
+func (j *Journal) Record(path string, content []byte) error {
+	now := time.Now()
+	entry := Entry{
+		ID:        now.Format("20060102-150405.000000000") + "-" + randomSuffix(),
+		Path:      path,
+		Written:   hash(content),
+		Time:      now,
+		SessionID: j.sessionID,
+	}
+
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", path)
+		}
+		original, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read original: %w", err)
+		}
+		entry.Existed = true
+		entry.Original = original
+		entry.Mode = info.Mode().Perm()
+	case !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("stat original: %w", err)
+	}
+
+	if err := os.MkdirAll(j.dir, 0700); err != nil {
+		return fmt.Errorf("create journal dir: %w", err)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal entry: %w", err)
+	}
+	if err := os.WriteFile(j.file(entry.ID), data, 0600); err != nil {
+		return fmt.Errorf("write entry: %w", err)
+	}
+	return nil
+}
+
+// Entries returns all records, oldest first. Unreadable records are skipped.
+func (j *Journal) Entries() ([]Entry, error) {
+	dirEntries, err := os.ReadDir(j.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read journal dir: %w", err)
+	}
+
+	var entries []Entry
+	for _, d := range dirEntries {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(j.dir, d.Name()))
+		if err != nil {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].ID < entries[b].ID
+	})
+	return entries, nil
+}
+
+// Undo restores the file to the state recorded in e and drops the record.
+// Files that did not exist before the write are deleted. Unless force is
+// set, Undo returns ErrChanged instead of touching a file whose content is
+// no longer what oli wrote.
+func (j *Journal) Undo(e Entry, force bool) error {
+	if !force {
+		if err := e.checkUnchanged(); err != nil {
+			return err
+		}
+	}
+
+	if e.Existed {
+		mode := e.Mode
+		if mode == 0 {
+			mode = 0644
+		}
+		if err := os.MkdirAll(filepath.Dir(e.Path), 0755); err != nil {
+			return fmt.Errorf("restore %s: %w", e.Path, err)
+		}
+		if err := atomicfile.Write(e.Path, e.Original, mode); err != nil {
+			return fmt.Errorf("restore %s: %w", e.Path, err)
+		}
+	} else if err := os.Remove(e.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove %s: %w", e.Path, err)
+	}
+
+	if err := os.Remove(j.file(e.ID)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("drop entry: %w", err)
+	}
+	return nil
+}
+
+// checkUnchanged reports ErrChanged if the file on disk differs from what
+// oli wrote. A missing file has nothing to lose.
+func (e Entry) checkUnchanged() error {
+	current, err := os.ReadFile(e.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", e.Path, err)
+	}
+	if hash(current) != e.Written {
+		return fmt.Errorf("%w: %s", ErrChanged, e.Path)
+	}
+	return nil
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (j *Journal) file(id string) string {
+	return filepath.Join(j.dir, id+".json")
+}
+
+func randomSuffix() string {
+	b := make([]byte, 2)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,117 @@
+package journal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// write records path in j and then writes content, as tools.WriteFile does.
+func write(t *testing.T, j *Journal, path, content string) Entry {
+	t.Helper()
+	if err := j.Record(path, []byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := j.Entries()
+	if err != nil || len(entries) == 0 {
+		t.Fatalf("Entries() = %v, %v", entries, err)
+	}
+	return entries[len(entries)-1]
+}
+
+func TestUndoRestoresOriginal(t *testing.T) {
+	dir := t.TempDir()
+	j := New(filepath.Join(dir, "journal"))
+	path := filepath.Join(dir, "a.txt")
+	os.WriteFile(path, []byte("original"), 0600)
+
+	e := write(t, j, path, "by oli")
+	if err := j.Undo(e, false); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != "original" {
+		t.Errorf("content = %q, want %q", got, "original")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	if entries, _ := j.Entries(); len(entries) != 0 {
+		t.Errorf("entry not dropped: %v", entries)
+	}
+}
+
+func TestUndoRefusesChangedFile(t *testing.T) {
+	dir := t.TempDir()
+	j := New(filepath.Join(dir, "journal"))
+	path := filepath.Join(dir, "a.txt")
+	os.WriteFile(path, []byte("original"), 0644)
+
+	e := write(t, j, path, "by oli")
+	os.WriteFile(path, []byte("by oli\nand the user"), 0644)
+
+	if err := j.Undo(e, false); !errors.Is(err, ErrChanged) {
+		t.Fatalf("Undo() = %v, want ErrChanged", err)
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != "by oli\nand the user" {
+		t.Errorf("file was touched: %q", got)
+	}
+
+	if err := j.Undo(e, true); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = os.ReadFile(path)
+	if string(got) != "original" {
+		t.Errorf("forced undo: content = %q", got)
+	}
+}
+
+func TestUndoKeepsEditedCreatedFile(t *testing.T) {
+	dir := t.TempDir()
+	j := New(filepath.Join(dir, "journal"))
+	path := filepath.Join(dir, "new.txt")
+
+	e := write(t, j, path, "created by oli")
+	os.WriteFile(path, []byte("edited"), 0644)
+
+	if err := j.Undo(e, false); !errors.Is(err, ErrChanged) {
+		t.Fatalf("Undo() = %v, want ErrChanged", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("edited file was removed: %v", err)
+	}
+
+	os.WriteFile(path, []byte("created by oli"), 0644)
+	if err := j.Undo(e, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("created file still exists: %v", err)
+	}
+}
+
+func TestUndoChainOfWrites(t *testing.T) {
+	dir := t.TempDir()
+	j := New(filepath.Join(dir, "journal"))
+	path := filepath.Join(dir, "a.txt")
+	os.WriteFile(path, []byte("v0"), 0644)
+
+	first := write(t, j, path, "v1")
+	second := write(t, j, path, "v2")
+
+	// Newest first, as undoCmd does: each step finds what oli wrote
+	for _, e := range []Entry{second, first} {
+		if err := j.Undo(e, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != "v0" {
+		t.Errorf("content = %q, want v0", got)
+	}
+}
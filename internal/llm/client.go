@@ -3,16 +3,116 @@ package llm
 import "context"
 
 // Client abstracts LLM interactions.
-// Designed for Ollama but can support other backends.
+// Implemented by OllamaClient and OpenAIClient.
 type Client interface {
 	// Generate sends a prompt and streams the response.
 	// The callback is invoked for each chunk of text received.
 	Generate(ctx context.Context, req GenerateRequest, onChunk func(chunk string)) error
+
+	// Chat sends a conversation and streams the assistant's reply.
+	// The callback is invoked for each chunk of text received; the
+	// returned Message holds the complete reply.
+	Chat(ctx context.Context, req ChatRequest, onChunk func(chunk string)) (Message, error)
+}
+
+// ModelInfo is implemented by clients that can report model metadata.
+type ModelInfo interface {
+	// ContextLength returns the maximum context window of the model, in tokens.
+	ContextLength(ctx context.Context, model string) (int, error)
+}
+
+// Embedder is implemented by clients that can compute embeddings.
+type Embedder interface {
+	// Embed returns one vector per input, in order.
+	Embed(ctx context.Context, model string, input []string) ([][]float32, error)
 }
 
 // GenerateRequest contains the parameters for generation.
 type GenerateRequest struct {
-	Model  string
-	Prompt string
-	System string // Optional system prompt
+	Model   string
+	Prompt  string
+	System  string   // Optional system prompt
+	Options *Options // Optional; nil uses the backend defaults
+}
+
+// Options are generation parameters. Unset fields (nil or zero) are not
+// sent, so the backend's defaults apply. The JSON names match Ollama's
+// "options" object.
+type Options struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	NumCtx      int      `json:"num_ctx,omitempty"` // Context window in tokens (Ollama only)
+	Stop        []string `json:"stop,omitempty"`
+}
+
+// Merge returns o with every field that is set in override replaced.
+func (o Options) Merge(override Options) Options {
+	if override.Temperature != nil {
+		o.Temperature = override.Temperature
+	}
+	if override.TopP != nil {
+		o.TopP = override.TopP
+	}
+	if override.Seed != nil {
+		o.Seed = override.Seed
+	}
+	if override.NumCtx != 0 {
+		o.NumCtx = override.NumCtx
+	}
+	if override.Stop != nil {
+		o.Stop = override.Stop
+	}
+	return o
+}
+
+// IsZero reports whether no option is set.
+func (o Options) IsZero() bool {
+	return o.Temperature == nil && o.TopP == nil && o.Seed == nil && o.NumCtx == 0 && len(o.Stop) == 0
+}
+
+// Roles used in chat messages.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// Message is a single role-tagged entry in a conversation.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+
+	// ToolCalls is set on assistant messages that request tool executions.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// ToolName identifies which tool produced a RoleTool message.
+	ToolName string `json:"tool_name,omitempty"`
+
+	// ToolCallID links a RoleTool message to the call it answers.
+	// Required by OpenAI-compatible backends; Ollama ignores it.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// ChatRequest contains the parameters for a chat exchange.
+type ChatRequest struct {
+	Model    string
+	Messages []Message // Full history, oldest first
+	Tools    []Tool    // Optional tools the model may call
+	Options  *Options  // Optional; nil uses the backend defaults
+}
+
+// Tool describes a function the model may request.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]any // JSON Schema for the arguments object
+}
+
+// ToolCall is a tool invocation requested by the model.
+type ToolCall struct {
+	ID        string         `json:"id,omitempty"`
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
 }
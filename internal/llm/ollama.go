@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 )
 
 type OllamaClient struct {
@@ -22,10 +23,11 @@ func NewOllamaClient(baseURL string) *OllamaClient {
 }
 
 type ollamaRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	System string `json:"system,omitempty"`
-	Stream bool   `json:"stream"`
+	Model   string   `json:"model"`
+	Prompt  string   `json:"prompt"`
+	System  string   `json:"system,omitempty"`
+	Options *Options `json:"options,omitempty"`
+	Stream  bool     `json:"stream"`
 }
 
 type ollamaResponse struct {
@@ -36,10 +38,11 @@ type ollamaResponse struct {
 
 func (c *OllamaClient) Generate(ctx context.Context, req GenerateRequest, onChunk func(string)) error {
 	body, err := json.Marshal(ollamaRequest{
-		Model:  req.Model,
-		Prompt: req.Prompt,
-		System: req.System,
-		Stream: true,
+		Model:   req.Model,
+		Prompt:  req.Prompt,
+		System:  req.System,
+		Options: ollamaOptions(req.Options),
+		Stream:  true,
 	})
 	if err != nil {
 		return fmt.Errorf("marshal request: %w", err)
@@ -81,3 +84,222 @@ func (c *OllamaClient) Generate(ctx context.Context, req GenerateRequest, onChun
 
 	return scanner.Err()
 }
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []ollamaTool    `json:"tools,omitempty"`
+	Options  *Options        `json:"options,omitempty"`
+	Stream   bool            `json:"stream"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaTool struct {
+	Type     string             `json:"type"`
+	Function ollamaToolFunction `json:"function"`
+}
+
+type ollamaToolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaChatResponse struct {
+	Message ollamaMessage `json:"message"`
+	Done    bool          `json:"done"`
+	Error   string        `json:"error,omitempty"`
+}
+
+func (c *OllamaClient) Chat(ctx context.Context, req ChatRequest, onChunk func(string)) (Message, error) {
+	messages := make([]ollamaMessage, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		msg := ollamaMessage{Role: m.Role, Content: m.Content, ToolName: m.ToolName}
+		for _, tc := range m.ToolCalls {
+			var call ollamaToolCall
+			call.Function.Name = tc.Name
+			call.Function.Arguments = tc.Arguments
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+		messages = append(messages, msg)
+	}
+
+	var tools []ollamaTool
+	for _, t := range req.Tools {
+		tools = append(tools, ollamaTool{
+			Type: "function",
+			Function: ollamaToolFunction{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		})
+	}
+
+	body, err := json.Marshal(ollamaChatRequest{
+		Model:    req.Model,
+		Messages: messages,
+		Tools:    tools,
+		Options:  ollamaOptions(req.Options),
+		Stream:   true,
+	})
+	if err != nil {
+		return Message{}, fmt.Errorf("marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return Message{}, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return Message{}, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Message{}, fmt.Errorf("ollama returned status %d", resp.StatusCode)
+	}
+
+	// Stream NDJSON response, accumulating the full reply
+	reply := Message{Role: RoleAssistant}
+	var content strings.Builder
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var chunk ollamaChatResponse
+		if err := json.Unmarshal(scanner.Bytes(), &chunk); err != nil {
+			continue // Skip malformed lines
+		}
+		if chunk.Error != "" {
+			return Message{}, fmt.Errorf("ollama error: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			onChunk(chunk.Message.Content)
+		}
+		for _, tc := range chunk.Message.ToolCalls {
+			reply.ToolCalls = append(reply.ToolCalls, ToolCall{
+				Name:      tc.Function.Name,
+				Arguments: tc.Function.Arguments,
+			})
+		}
+		if chunk.Done {
+			break
+		}
+	}
+
+	reply.Content = content.String()
+	return reply, scanner.Err()
+}
+
+// ollamaOptions omits the options object entirely when nothing is set.
+func ollamaOptions(o *Options) *Options {
+	if o == nil || o.IsZero() {
+		return nil
+	}
+	return o
+}
+
+type ollamaShowResponse struct {
+	ModelInfo map[string]any `json:"model_info"`
+}
+
+// ContextLength queries /api/show for the model's trained context length,
+// reported under "<architecture>.context_length".
+func (c *OllamaClient) ContextLength(ctx context.Context, model string) (int, error) {
+	body, err := json.Marshal(map[string]string{"model": model})
+	if err != nil {
+		return 0, fmt.Errorf("marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/show", bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return 0, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("ollama returned status %d", resp.StatusCode)
+	}
+
+	var show ollamaShowResponse
+	if err := json.NewDecoder(resp.Body).Decode(&show); err != nil {
+		return 0, fmt.Errorf("decode response: %w", err)
+	}
+	for key, value := range show.ModelInfo {
+		if n, ok := value.(float64); ok && strings.HasSuffix(key, ".context_length") {
+			return int(n), nil
+		}
+	}
+	return 0, fmt.Errorf("context length not reported for %s", model)
+}
+
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// Embed computes embeddings through /api/embed.
+func (c *OllamaClient) Embed(ctx context.Context, model string, input []string) ([][]float32, error) {
+	body, err := json.Marshal(ollamaEmbedRequest{Model: model, Input: input})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var embed ollamaEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embed); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("ollama returned status %d", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	if embed.Error != "" {
+		return nil, fmt.Errorf("ollama error: %s", embed.Error)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama returned status %d", resp.StatusCode)
+	}
+	if len(embed.Embeddings) != len(input) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(input), len(embed.Embeddings))
+	}
+	return embed.Embeddings, nil
+}
@@ -0,0 +1,256 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// OpenAIClient talks to any server implementing the OpenAI
+// /v1/chat/completions streaming protocol (llama.cpp server, vLLM,
+// LM Studio, OpenAI itself).
+type OpenAIClient struct {
+	baseURL    string // Including the version prefix, e.g. http://localhost:8080/v1
+	apiKey     string
+	httpClient *http.Client
+}
+
+func NewOpenAIClient(baseURL, apiKey string) *OpenAIClient {
+	return &OpenAIClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{},
+	}
+}
+
+type openaiRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openaiMessage `json:"messages"`
+	Tools       []openaiTool    `json:"tools,omitempty"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	TopP        *float64        `json:"top_p,omitempty"`
+	Seed        *int            `json:"seed,omitempty"`
+	Stop        []string        `json:"stop,omitempty"`
+	Stream      bool            `json:"stream"`
+}
+
+type openaiMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openaiToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+	Name       string           `json:"name,omitempty"`
+}
+
+type openaiTool struct {
+	Type     string             `json:"type"`
+	Function openaiToolFunction `json:"function"`
+}
+
+type openaiToolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+type openaiToolCall struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"` // JSON text, streamed in fragments
+	} `json:"function"`
+}
+
+type openaiStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content   string           `json:"content"`
+			ToolCalls []openaiToolCall `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Error *openaiError `json:"error,omitempty"`
+}
+
+type openaiError struct {
+	Message string `json:"message"`
+}
+
+func (c *OpenAIClient) Generate(ctx context.Context, req GenerateRequest, onChunk func(string)) error {
+	var messages []Message
+	if req.System != "" {
+		messages = append(messages, Message{Role: RoleSystem, Content: req.System})
+	}
+	messages = append(messages, Message{Role: RoleUser, Content: req.Prompt})
+
+	_, err := c.Chat(ctx, ChatRequest{Model: req.Model, Messages: messages, Options: req.Options}, onChunk)
+	return err
+}
+
+func (c *OpenAIClient) Chat(ctx context.Context, req ChatRequest, onChunk func(string)) (Message, error) {
+	messages := make([]openaiMessage, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		msg := openaiMessage{Role: m.Role, Content: m.Content, ToolCallID: m.ToolCallID}
+		if m.Role == RoleTool {
+			msg.Name = m.ToolName
+		}
+		for _, tc := range m.ToolCalls {
+			args, err := json.Marshal(tc.Arguments)
+			if err != nil {
+				return Message{}, fmt.Errorf("marshal tool arguments: %w", err)
+			}
+			var call openaiToolCall
+			call.ID = tc.ID
+			call.Type = "function"
+			call.Function.Name = tc.Name
+			call.Function.Arguments = string(args)
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+		messages = append(messages, msg)
+	}
+
+	var tools []openaiTool
+	for _, t := range req.Tools {
+		tools = append(tools, openaiTool{
+			Type: "function",
+			Function: openaiToolFunction{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		})
+	}
+
+	wire := openaiRequest{
+		Model:    req.Model,
+		Messages: messages,
+		Tools:    tools,
+		Stream:   true,
+	}
+	// num_ctx has no equivalent: the server fixes the context size at load time
+	if o := req.Options; o != nil {
+		wire.Temperature = o.Temperature
+		wire.TopP = o.TopP
+		wire.Seed = o.Seed
+		wire.Stop = o.Stop
+	}
+
+	body, err := json.Marshal(wire)
+	if err != nil {
+		return Message{}, fmt.Errorf("marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return Message{}, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return Message{}, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Message{}, statusError(resp)
+	}
+
+	reply := Message{Role: RoleAssistant}
+	var content strings.Builder
+	calls := make(map[int]*openaiToolCall) // Fragments keyed by call index
+
+	// Stream SSE: "data: {json}" lines, terminated by "data: [DONE]"
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue // Comments, event names and blank separators
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openaiStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue // Skip malformed events
+		}
+		if chunk.Error != nil {
+			return Message{}, fmt.Errorf("openai error: %s", chunk.Error.Message)
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				content.WriteString(choice.Delta.Content)
+				onChunk(choice.Delta.Content)
+			}
+			for _, tc := range choice.Delta.ToolCalls {
+				call, ok := calls[tc.Index]
+				if !ok {
+					call = &openaiToolCall{Index: tc.Index}
+					calls[tc.Index] = call
+				}
+				if tc.ID != "" {
+					call.ID = tc.ID
+				}
+				call.Function.Name += tc.Function.Name
+				call.Function.Arguments += tc.Function.Arguments
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Message{}, err
+	}
+
+	reply.Content = content.String()
+
+	indexes := make([]int, 0, len(calls))
+	for i := range calls {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	for _, i := range indexes {
+		call := calls[i]
+		args := map[string]any{}
+		if raw := strings.TrimSpace(call.Function.Arguments); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &args); err != nil {
+				return Message{}, fmt.Errorf("parse arguments for tool %s: %w", call.Function.Name, err)
+			}
+		}
+		reply.ToolCalls = append(reply.ToolCalls, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: args,
+		})
+	}
+
+	return reply, nil
+}
+
+// statusError builds an error from a non-200 response, including the
+// server's error message when it sends one.
+func statusError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	var body struct {
+		Error *openaiError `json:"error"`
+	}
+	if json.Unmarshal(data, &body) == nil && body.Error != nil && body.Error.Message != "" {
+		return fmt.Errorf("server returned status %d: %s", resp.StatusCode, body.Error.Message)
+	}
+	return fmt.Errorf("server returned status %d", resp.StatusCode)
+}
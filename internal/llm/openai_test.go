@@ -0,0 +1,186 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sseServer answers /v1/chat/completions with the given SSE events and
+// records the last request body.
+func sseServer(t *testing.T, events []string, got *openaiRequest) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		if got != nil {
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, got); err != nil {
+				t.Errorf("request body: %v", err)
+			}
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			fmt.Fprintf(w, "%s\n\n", e)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOpenAIChatStreamsContent(t *testing.T) {
+	var req openaiRequest
+	srv := sseServer(t, []string{
+		`: keep-alive comment`,
+		`data: {"choices":[{"delta":{"role":"assistant","content":"Hola"}}]}`,
+		`data: {"choices":[{"delta":{"content":", mundo"}}]}`,
+		`data: not json`,
+		`data: {"choices":[{"delta":{},"finish_reason":"stop"}]}`,
+		`data: [DONE]`,
+		`data: {"choices":[{"delta":{"content":"after done"}}]}`,
+	}, &req)
+
+	temp := 0.2
+	var chunks []string
+	reply, err := NewOpenAIClient(srv.URL+"/v1/", "").Chat(context.Background(), ChatRequest{
+		Model:    "m",
+		Messages: []Message{{Role: RoleUser, Content: "hi"}},
+		Options:  &Options{Temperature: &temp, NumCtx: 4096},
+	}, func(s string) { chunks = append(chunks, s) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reply.Content != "Hola, mundo" {
+		t.Errorf("Content = %q", reply.Content)
+	}
+	if !reflect.DeepEqual(chunks, []string{"Hola", ", mundo"}) {
+		t.Errorf("chunks = %q", chunks)
+	}
+	if !req.Stream || req.Model != "m" || req.Temperature == nil || *req.Temperature != 0.2 {
+		t.Errorf("request = %+v", req)
+	}
+}
+
+func TestOpenAIChatAssemblesToolCallDeltas(t *testing.T) {
+	srv := sseServer(t, []string{
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"read_","arguments":""}}]}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"name":"file","arguments":"{\"pa"}}]}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":1,"id":"call_2","function":{"name":"list_dir","arguments":"{}"}}]}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"th\":\"main.go\"}"}}]}}]}`,
+		`data: {"choices":[{"delta":{},"finish_reason":"tool_calls"}]}`,
+		`data: [DONE]`,
+	}, nil)
+
+	reply, err := NewOpenAIClient(srv.URL+"/v1", "").Chat(context.Background(), ChatRequest{
+		Model:    "m",
+		Messages: []Message{{Role: RoleUser, Content: "read main.go"}},
+		Tools:    []Tool{{Name: "read_file"}, {Name: "list_dir"}},
+	}, func(string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ToolCall{
+		{ID: "call_1", Name: "read_file", Arguments: map[string]any{"path": "main.go"}},
+		{ID: "call_2", Name: "list_dir", Arguments: map[string]any{}},
+	}
+	if !reflect.DeepEqual(reply.ToolCalls, want) {
+		t.Errorf("ToolCalls = %+v, want %+v", reply.ToolCalls, want)
+	}
+}
+
+func TestOpenAIChatStreamError(t *testing.T) {
+	srv := sseServer(t, []string{
+		`data: {"choices":[{"delta":{"content":"par"}}]}`,
+		`data: {"error":{"message":"context length exceeded"}}`,
+	}, nil)
+
+	_, err := NewOpenAIClient(srv.URL+"/v1", "").Chat(context.Background(), ChatRequest{Model: "m"}, func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "context length exceeded") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestOpenAIChatStatusErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"json error body", http.StatusBadRequest, `{"error":{"message":"model not found"}}`, "status 400: model not found"},
+		{"plain body", http.StatusInternalServerError, "boom", "status 500"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			_, err := NewOpenAIClient(srv.URL, "").Chat(context.Background(), ChatRequest{Model: "m"}, func(string) {})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIChatAPIKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, `{"error":{"message":"missing or invalid API key"}}`)
+			return
+		}
+		io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	_, err := NewOpenAIClient(srv.URL, "").Chat(context.Background(), ChatRequest{Model: "m"}, func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "status 401: missing or invalid API key") {
+		t.Errorf("without key: err = %v", err)
+	}
+
+	reply, err := NewOpenAIClient(srv.URL, "sk-test").Chat(context.Background(), ChatRequest{Model: "m"}, func(string) {})
+	if err != nil || reply.Content != "ok" {
+		t.Errorf("with key: reply = %+v, err = %v", reply, err)
+	}
+}
+
+func TestOpenAIChatSendsToolHistory(t *testing.T) {
+	var req openaiRequest
+	srv := sseServer(t, []string{`data: [DONE]`}, &req)
+
+	_, err := NewOpenAIClient(srv.URL+"/v1", "").Chat(context.Background(), ChatRequest{
+		Model: "m",
+		Messages: []Message{
+			{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "c1", Name: "list_dir", Arguments: map[string]any{"path": "."}}}},
+			{Role: RoleTool, Content: "a.go", ToolName: "list_dir", ToolCallID: "c1"},
+		},
+	}, func(string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(req.Messages) != 2 {
+		t.Fatalf("messages = %+v", req.Messages)
+	}
+	call := req.Messages[0].ToolCalls[0]
+	if call.ID != "c1" || call.Type != "function" || call.Function.Name != "list_dir" || call.Function.Arguments != `{"path":"."}` {
+		t.Errorf("tool call = %+v", call)
+	}
+	if tool := req.Messages[1]; tool.Role != RoleTool || tool.ToolCallID != "c1" || tool.Name != "list_dir" {
+		t.Errorf("tool message = %+v", tool)
+	}
+}
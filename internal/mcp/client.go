@@ -0,0 +1,339 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProtocolVersion is the MCP revision oli speaks.
+const ProtocolVersion = "2025-06-18"
+
+// initTimeout bounds the handshake, so a server that never answers does
+// not hang oli.
+const initTimeout = 15 * time.Second
+
+// ServerConfig describes an MCP server launched as a subprocess.
+type ServerConfig struct {
+	Name    string
+	Command string
+	Args    []string
+	Env     map[string]string
+}
+
+// Implementation identifies a client or server in the handshake.
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// ToolInfo is a tool offered by a server.
+type ToolInfo struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// ResourceInfo is a resource offered by a server.
+type ResourceInfo struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// PromptInfo is a prompt template offered by a server.
+type PromptInfo struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument is a parameter of a prompt template.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// ResourceContents is one item returned by resources/read.
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// Content is one item of a tool result.
+type Content struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	MimeType string            `json:"mimeType,omitempty"`
+	Resource *ResourceContents `json:"resource,omitempty"`
+}
+
+// ToolResult is the result of tools/call.
+type ToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Text joins the textual parts of a tool result.
+func (r ToolResult) Text() string {
+	var parts []string
+	for _, c := range r.Content {
+		switch {
+		case c.Type == "text":
+			parts = append(parts, c.Text)
+		case c.Resource != nil && c.Resource.Text != "":
+			parts = append(parts, c.Resource.Text)
+		default:
+			parts = append(parts, fmt.Sprintf("[%s omitido]", c.Type))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+type initializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      Implementation `json:"serverInfo"`
+	Instructions    string         `json:"instructions,omitempty"`
+}
+
+// Client is a connection to an MCP server running as a subprocess over
+// the stdio transport.
+type Client struct {
+	name   string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	conn   *Conn
+	stderr *tailBuffer
+	done   chan struct{}
+
+	info initializeResult
+}
+
+// StartServer launches the server and performs the initialize handshake.
+func StartServer(ctx context.Context, cfg ServerConfig) (*Client, error) {
+	cmd := exec.Command(cfg.Command, cfg.Args...)
+	cmd.Env = os.Environ()
+	for k, v := range cfg.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr := &tailBuffer{max: 4096}
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", cfg.Name, err)
+	}
+
+	c := &Client{
+		name:   cfg.Name,
+		cmd:    cmd,
+		stdin:  stdin,
+		conn:   NewConn(stdout, stdin, nil),
+		stderr: stderr,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		c.conn.Serve(context.Background())
+		close(c.done)
+	}()
+
+	initCtx, cancel := context.WithTimeout(ctx, initTimeout)
+	defer cancel()
+	err = c.conn.Call(initCtx, "initialize", map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      Implementation{Name: "oli", Version: "1.0"},
+	}, &c.info)
+	if err == nil {
+		err = c.conn.Notify("notifications/initialized", nil)
+	}
+	if err != nil {
+		c.Close()
+		return nil, c.wrap("initialize", err)
+	}
+	return c, nil
+}
+
+// Name returns the configured server name.
+func (c *Client) Name() string {
+	return c.name
+}
+
+// ServerInfo returns what the server reported about itself.
+func (c *Client) ServerInfo() Implementation {
+	return c.info.ServerInfo
+}
+
+// Instructions returns the usage hints the server sent, if any.
+func (c *Client) Instructions() string {
+	return c.info.Instructions
+}
+
+func (c *Client) hasCapability(name string) bool {
+	_, ok := c.info.Capabilities[name]
+	return ok
+}
+
+// ListTools returns every tool, following pagination.
+func (c *Client) ListTools(ctx context.Context) ([]ToolInfo, error) {
+	if !c.hasCapability("tools") {
+		return nil, nil
+	}
+	var all []ToolInfo
+	err := c.paginate(ctx, "tools/list", func(raw json.RawMessage) (string, error) {
+		var page struct {
+			Tools      []ToolInfo `json:"tools"`
+			NextCursor string     `json:"nextCursor"`
+		}
+		err := json.Unmarshal(raw, &page)
+		all = append(all, page.Tools...)
+		return page.NextCursor, err
+	})
+	return all, err
+}
+
+// ListResources returns every resource, following pagination.
+func (c *Client) ListResources(ctx context.Context) ([]ResourceInfo, error) {
+	if !c.hasCapability("resources") {
+		return nil, nil
+	}
+	var all []ResourceInfo
+	err := c.paginate(ctx, "resources/list", func(raw json.RawMessage) (string, error) {
+		var page struct {
+			Resources  []ResourceInfo `json:"resources"`
+			NextCursor string         `json:"nextCursor"`
+		}
+		err := json.Unmarshal(raw, &page)
+		all = append(all, page.Resources...)
+		return page.NextCursor, err
+	})
+	return all, err
+}
+
+// ListPrompts returns every prompt, following pagination.
+func (c *Client) ListPrompts(ctx context.Context) ([]PromptInfo, error) {
+	if !c.hasCapability("prompts") {
+		return nil, nil
+	}
+	var all []PromptInfo
+	err := c.paginate(ctx, "prompts/list", func(raw json.RawMessage) (string, error) {
+		var page struct {
+			Prompts    []PromptInfo `json:"prompts"`
+			NextCursor string       `json:"nextCursor"`
+		}
+		err := json.Unmarshal(raw, &page)
+		all = append(all, page.Prompts...)
+		return page.NextCursor, err
+	})
+	return all, err
+}
+
+// ReadResource returns the contents of a resource.
+func (c *Client) ReadResource(ctx context.Context, uri string) ([]ResourceContents, error) {
+	var result struct {
+		Contents []ResourceContents `json:"contents"`
+	}
+	if err := c.conn.Call(ctx, "resources/read", map[string]string{"uri": uri}, &result); err != nil {
+		return nil, c.wrap("resources/read", err)
+	}
+	return result.Contents, nil
+}
+
+// CallTool invokes a tool. A tool that fails reports it in the result
+// (IsError), not as an error.
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]any) (ToolResult, error) {
+	if args == nil {
+		args = map[string]any{}
+	}
+	var result ToolResult
+	if err := c.conn.Call(ctx, "tools/call", map[string]any{"name": name, "arguments": args}, &result); err != nil {
+		return ToolResult{}, c.wrap("tools/call", err)
+	}
+	return result, nil
+}
+
+// Close shuts the server down: stdin is closed so it can exit on its own,
+// and it is killed if it has not after a grace period.
+func (c *Client) Close() error {
+	c.stdin.Close()
+
+	select {
+	case <-c.done:
+	case <-time.After(2 * time.Second):
+		c.cmd.Process.Kill()
+	}
+	return c.cmd.Wait()
+}
+
+func (c *Client) paginate(ctx context.Context, method string, page func(json.RawMessage) (string, error)) error {
+	cursor := ""
+	for {
+		var params map[string]string
+		if cursor != "" {
+			params = map[string]string{"cursor": cursor}
+		}
+		var raw json.RawMessage
+		if err := c.conn.Call(ctx, method, params, &raw); err != nil {
+			return c.wrap(method, err)
+		}
+		next, err := page(raw)
+		if err != nil {
+			return c.wrap(method, err)
+		}
+		if next == "" || next == cursor {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// wrap adds the server name and, when the server died, its last stderr.
+func (c *Client) wrap(method string, err error) error {
+	if tail := strings.TrimSpace(c.stderr.String()); tail != "" && errors.Is(err, ErrConnClosed) {
+		return fmt.Errorf("%s: %s: %w: %s", c.name, method, err, tail)
+	}
+	return fmt.Errorf("%s: %s: %w", c.name, method, err)
+}
+
+// tailBuffer keeps the last max bytes written to it.
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = b.buf[len(b.buf)-b.max:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
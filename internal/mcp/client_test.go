@@ -0,0 +1,176 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// startFake launches this test binary as a fake MCP server; see
+// TestHelperProcess.
+func startFake(t *testing.T) *Client {
+	t.Helper()
+	c, err := StartServer(context.Background(), ServerConfig{
+		Name:    "fake",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestHelperProcess$"},
+		Env:     map[string]string{"GO_WANT_HELPER_PROCESS": "1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// TestHelperProcess is not a real test: it is the fake server started by
+// startFake, speaking MCP on stdin and stdout through Conn.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		t.Skip("helper process")
+	}
+	conn := NewConn(os.Stdin, os.Stdout, fakeServer)
+	conn.Serve(context.Background())
+	os.Exit(0)
+}
+
+func fakeServer(ctx context.Context, method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "initialize":
+		return map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}, "resources": map[string]any{}},
+			"serverInfo":      Implementation{Name: "fake", Version: "0.1"},
+			"instructions":    "usa echo",
+		}, nil
+	case "notifications/initialized":
+		return nil, nil
+	case "tools/list":
+		// Two pages, to exercise nextCursor
+		var p struct {
+			Cursor string `json:"cursor"`
+		}
+		json.Unmarshal(params, &p)
+		if p.Cursor == "" {
+			return map[string]any{"tools": []ToolInfo{{Name: "echo"}}, "nextCursor": "2"}, nil
+		}
+		return map[string]any{"tools": []ToolInfo{{Name: "crash"}}}, nil
+	case "tools/call":
+		var p struct {
+			Name      string         `json:"name"`
+			Arguments map[string]any `json:"arguments"`
+		}
+		json.Unmarshal(params, &p)
+		if p.Name == "crash" {
+			fmt.Fprintln(os.Stderr, "fake: crashing")
+			os.Exit(3)
+		}
+		return ToolResult{Content: []Content{{Type: "text", Text: fmt.Sprint(p.Arguments["text"])}}}, nil
+	case "resources/read":
+		var p struct {
+			URI string `json:"uri"`
+		}
+		json.Unmarshal(params, &p)
+		switch p.URI {
+		case "fake://readme":
+			return map[string]any{"contents": []ResourceContents{{URI: p.URI, MimeType: "text/plain", Text: "hola"}}}, nil
+		case "fake://guide":
+			return map[string]any{"contents": []ResourceContents{{URI: p.URI, MimeType: "text/markdown", Text: "# Guía\n\n```\nmake\n```"}}}, nil
+		}
+		return nil, &RPCError{Code: CodeInvalidParams, Message: "unknown resource " + p.URI}
+	}
+	return nil, &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + method}
+}
+
+func TestClientInitialize(t *testing.T) {
+	c := startFake(t)
+	if got := c.ServerInfo(); got != (Implementation{Name: "fake", Version: "0.1"}) {
+		t.Errorf("ServerInfo() = %+v", got)
+	}
+	if c.Instructions() != "usa echo" {
+		t.Errorf("Instructions() = %q", c.Instructions())
+	}
+	// No prompts capability: no request is sent
+	if prompts, err := c.ListPrompts(context.Background()); err != nil || prompts != nil {
+		t.Errorf("ListPrompts() = %v, %v", prompts, err)
+	}
+}
+
+func TestClientListAndCallTools(t *testing.T) {
+	c := startFake(t)
+	ctx := context.Background()
+
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"echo", "crash"}) {
+		t.Errorf("tools = %q", names)
+	}
+
+	result, err := c.CallTool(ctx, "echo", map[string]any{"text": "hola"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError || result.Text() != "hola" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestClientReadResource(t *testing.T) {
+	c := startFake(t)
+	ctx := context.Background()
+
+	contents, err := c.ReadResource(ctx, "fake://readme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contents) != 1 || contents[0].Text != "hola" {
+		t.Errorf("contents = %+v", contents)
+	}
+
+	_, err = c.ReadResource(ctx, "fake://missing")
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidParams {
+		t.Fatalf("err = %v, want RPCError %d", err, CodeInvalidParams)
+	}
+
+	// The connection survives an error response
+	if _, err := c.ReadResource(ctx, "fake://readme"); err != nil {
+		t.Errorf("after error: %v", err)
+	}
+}
+
+func TestClientServerExitsMidCall(t *testing.T) {
+	c := startFake(t)
+	ctx := context.Background()
+
+	_, err := c.CallTool(ctx, "crash", nil)
+	if !errors.Is(err, ErrConnClosed) {
+		t.Fatalf("err = %v, want ErrConnClosed", err)
+	}
+	if _, err := c.CallTool(ctx, "echo", nil); !errors.Is(err, ErrConnClosed) {
+		t.Errorf("call after exit: err = %v, want ErrConnClosed", err)
+	}
+}
+
+func TestResourceProviderFencesMarkdown(t *testing.T) {
+	c := startFake(t)
+	got, err := NewResourceProvider(c, []string{"fake://guide", "fake://missing"}).Gather(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "### fake://guide\n````\n# Guía\n\n```\nmake\n```\n````\n\nRecursos omitidos: fake://missing (error: "
+	if !strings.HasPrefix(got.Content, want) {
+		t.Errorf("Content =\n%s\nwant prefix\n%s", got.Content, want)
+	}
+}
@@ -6,12 +6,17 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"ollama-cli/internal/ignore"
 )
 
 // Configuración de límites
 const (
 	maxFileSize    = 50000  // 50KB máximo por archivo
 	maxTotalSize   = 200000 // 200KB máximo total de contenido
+
+	maxCandidates = 5000    // Archivos considerados al ordenar por relevancia
+	maxRankSize   = 8000000 // 8MB máximo leído para puntuar contenidos
 )
 
 // Extensiones de archivos que se leen automáticamente
@@ -59,55 +64,23 @@ var readableExtensions = map[string]bool{
 	"Rakefile": true,
 }
 
-// Directorios a ignorar
-var ignoredDirs = map[string]bool{
-	"node_modules":    true,
-	"vendor":          true,
-	"__pycache__":     true,
-	"dist":            true,
-	"build":           true,
-	".git":            true,
-	"bin":             true,
-	"obj":             true,
-	"target":          true,
-	".idea":           true,
-	".vscode":         true,
-	"coverage":        true,
-	".next":           true,
-	".nuxt":           true,
-	"venv":            true,
-	".venv":           true,
-	"env":             true,
-	".env":            true,
-	"__snapshots__":   true,
-	".cache":          true,
-	".parcel-cache":   true,
-	".turbo":          true,
-	"tmp":             true,
-	"temp":            true,
-	"logs":            true,
-	".pytest_cache":   true,
-	".mypy_cache":     true,
-	".tox":            true,
-	"htmlcov":         true,
-	".coverage":       true,
-	"eggs":            true,
-	".eggs":           true,
-	"wheels":          true,
-	"pip-wheel-metadata": true,
-	"*.egg-info":      true,
-	".installed.cfg":  true,
-	"lib":             true,
-	"lib64":           true,
-	"parts":           true,
-	"sdist":           true,
-	"var":             true,
-	".sass-cache":     true,
-	"bower_components": true,
-	"jspm_packages":   true,
-	".npm":            true,
-	".yarn":           true,
-	".pnp":            true,
+// Patrones que se ignoran siempre, antes de los .gitignore y .oliignore del
+// proyecto (que pueden volver a incluirlos con "!"). Cubren dependencias y
+// artefactos de compilación en proyectos sin .gitignore.
+var defaultIgnore = []string{
+	"node_modules/",
+	"bower_components/",
+	"jspm_packages/",
+	"__pycache__/",
+	"*.egg-info/",
+	"venv/",
+	"__snapshots__/",
+	"htmlcov/",
+	"pip-wheel-metadata/",
+	"dist/",
+	"build/",
+	"target/",
+	"coverage/",
 }
 
 // Archivos a ignorar
@@ -125,129 +98,272 @@ var ignoredFiles = map[string]bool{
 }
 
 type FilesystemProvider struct {
-	maxFiles int
-	maxDepth int
+	maxFiles     int
+	maxDepth     int
+	maxTotalSize int
+
+	// onRead recibe cada archivo cuyo contenido se envía al modelo
+	onRead func(path string, content []byte)
 }
 
 func NewFilesystemProvider(maxFiles, maxDepth int) *FilesystemProvider {
 	return &FilesystemProvider{
-		maxFiles: maxFiles,
-		maxDepth: maxDepth,
+		maxFiles:     maxFiles,
+		maxDepth:     maxDepth,
+		maxTotalSize: maxTotalSize,
 	}
 }
 
+// SetMaxSize ajusta el límite total de contenido al presupuesto del prompt,
+// sin superar nunca maxTotalSize.
+func (p *FilesystemProvider) SetMaxSize(bytes int) {
+	p.maxTotalSize = min(max(bytes, 0), maxTotalSize)
+}
+
+// SetOnRead registra fn para cada archivo que entra en el contexto, de modo
+// que quien escribe después pueda detectar si cambió desde entonces.
+func (p *FilesystemProvider) SetOnRead(fn func(path string, content []byte)) {
+	p.onRead = fn
+}
+
 func (p *FilesystemProvider) Name() string {
 	return "filesystem"
 }
 
+// Gather recorre workDir, ordena los archivos por relevancia para la tarea
+// (ver WithTask) y llena el presupuesto de contenido en ese orden.
 func (p *FilesystemProvider) Gather(ctx context.Context, workDir string) (ContextResult, error) {
+	files, err := p.collect(ctx, workDir)
+	if err != nil {
+		return ContextResult{Provider: p.Name()}, err
+	}
+
+	rankCandidates(ctx, workDir, TaskFrom(ctx), files)
+
 	var fileContents []string
 	var fileList []string
-	count := 0
 	totalSize := 0
 
-	err := filepath.WalkDir(workDir, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return nil
+	for i, c := range files {
+		// Verificar si alcanzamos el límite de archivos
+		if i >= p.maxFiles {
+			break
 		}
 
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+		if !c.readable {
+			// Solo listar el archivo sin leer contenido
+			fileList = append(fileList, c.rel)
+			continue
 		}
 
-		name := d.Name()
+		if c.size > maxFileSize {
+			fileList = append(fileList, fmt.Sprintf("%s (muy grande: %dKB)", c.rel, c.size/1024))
+			continue
+		}
 
-		// Ignorar directorios
-		if d.IsDir() {
-			if strings.HasPrefix(name, ".") || ignoredDirs[name] {
-				return filepath.SkipDir
+		// Verificar límite total
+		if totalSize+c.size > p.maxTotalSize {
+			fileList = append(fileList, fmt.Sprintf("%s (omitido por límite de contexto)", c.rel))
+			continue
+		}
+
+		// Los que no se cargaron al puntuar se leen ahora
+		if !c.loaded {
+			content, err := os.ReadFile(c.path)
+			if err != nil {
+				fileList = append(fileList, fmt.Sprintf("%s (error al leer)", c.rel))
+				continue
 			}
-			return nil
+			c.content = string(content)
 		}
 
-		// Ignorar archivos ocultos y de lock
-		if strings.HasPrefix(name, ".") || ignoredFiles[name] {
-			return nil
+		fence := CodeFence(c.content)
+		fileContents = append(fileContents, fmt.Sprintf("### %s\n%s\n%s\n%s", c.rel, fence, c.content, fence))
+		totalSize += c.size
+		if p.onRead != nil {
+			p.onRead(c.path, []byte(c.content))
 		}
+	}
 
-		// Verificar profundidad
-		rel, _ := filepath.Rel(workDir, path)
-		depth := strings.Count(rel, string(os.PathSeparator))
-		if depth > p.maxDepth {
-			return nil
+	// Construir resultado
+	var sb strings.Builder
+
+	if len(fileContents) > 0 {
+		sb.WriteString("## Contenido de archivos del proyecto\n\n")
+		sb.WriteString(strings.Join(fileContents, "\n\n"))
+	}
+
+	if len(fileList) > 0 {
+		sb.WriteString("\n\n## Otros archivos (sin contenido)\n")
+		sb.WriteString(strings.Join(fileList, "\n"))
+	}
+
+	return ContextResult{
+		Provider: p.Name(),
+		Content:  sb.String(),
+	}, nil
+}
+
+// CodeFence devuelve una valla de código más larga que cualquiera de las
+// que contiene content, para que un Markdown con bloques de código (un
+// archivo, un diff o un recurso) no cierre antes de tiempo el bloque que
+// lo envuelve.
+func CodeFence(content string) string {
+	n := 3
+	for _, line := range strings.Split(content, "\n") {
+		t := strings.TrimSpace(line)
+		if run := len(t) - len(strings.TrimLeft(t, "`")); run >= n {
+			n = run + 1
 		}
+	}
+	return strings.Repeat("`", n)
+}
 
-		// Verificar si alcanzamos el límite de archivos
-		if count >= p.maxFiles {
+// collect recorre workDir y devuelve los archivos candidatos con el
+// contenido de los legibles ya cargado, para poder puntuarlos.
+func (p *FilesystemProvider) collect(ctx context.Context, workDir string) ([]*candidate, error) {
+	var files []*candidate
+	loadedSize := 0
+
+	err := walkProject(ctx, workDir, p.maxDepth, func(rel, path string, d os.DirEntry) error {
+		if len(files) >= maxCandidates {
 			return filepath.SkipAll
 		}
 
-		// Verificar si es un archivo legible
-		ext := filepath.Ext(name)
-		isReadable := readableExtensions[ext] || readableExtensions[name]
-
-		if isReadable {
-			// Verificar tamaño del archivo
+		c := &candidate{rel: rel, path: path, readable: isReadable(d.Name())}
+		if c.readable {
 			info, err := d.Info()
 			if err != nil {
 				return nil
 			}
+			c.size = int(info.Size())
 
-			fileSize := int(info.Size())
-			if fileSize > maxFileSize {
-				fileList = append(fileList, fmt.Sprintf("%s (muy grande: %dKB)", rel, fileSize/1024))
-				count++
-				return nil
+			if c.size <= maxFileSize && loadedSize+c.size <= maxRankSize {
+				if content, err := os.ReadFile(path); err == nil {
+					c.content = string(content)
+					c.loaded = true
+					loadedSize += c.size
+				}
 			}
+		}
 
-			// Verificar límite total
-			if totalSize+fileSize > maxTotalSize {
-				fileList = append(fileList, fmt.Sprintf("%s (omitido por límite de contexto)", rel))
-				count++
-				return nil
-			}
+		files = append(files, c)
+		return nil
+	})
 
-			// Leer contenido
-			content, err := os.ReadFile(path)
-			if err != nil {
-				fileList = append(fileList, fmt.Sprintf("%s (error al leer)", rel))
-				count++
-				return nil
+	return files, err
+}
+
+// ProjectFiles devuelve las rutas (relativas a workDir) de los archivos de
+// texto legibles del proyecto, con las mismas reglas de exclusión que el
+// contexto automático.
+func ProjectFiles(ctx context.Context, workDir string, maxDepth int) ([]string, error) {
+	var files []string
+	err := walkProject(ctx, workDir, maxDepth, func(rel, path string, d os.DirEntry) error {
+		if isReadable(d.Name()) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, err
+}
+
+func isReadable(name string) bool {
+	return readableExtensions[filepath.Ext(name)] || readableExtensions[name]
+}
+
+// walkProject llama a fn con cada archivo de workDir que no esté excluido
+// (patrones de .gitignore y .oliignore, además de defaultIgnore, archivos
+// ocultos y de lock) hasta maxDepth niveles de profundidad. fn puede
+// devolver filepath.SkipAll.
+func walkProject(ctx context.Context, workDir string, maxDepth int, fn func(rel, path string, d os.DirEntry) error) error {
+	matcher, prefix := projectIgnores(workDir)
+
+	return filepath.WalkDir(workDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		name := d.Name()
+		rel, _ := filepath.Rel(workDir, path)
+		// Ruta relativa a la raíz del repositorio, como en los .gitignore
+		repoRel := ignore.Join(prefix, filepath.ToSlash(rel))
+
+		// Ignorar directorios
+		if d.IsDir() {
+			if path != workDir && (strings.HasPrefix(name, ".") || matcher.Match(repoRel, true)) {
+				return filepath.SkipDir
 			}
+			if path == workDir {
+				repoRel = prefix
+			}
+			// Las reglas de cada carpeta valen para su contenido; .oliignore
+			// va después para poder corregir al .gitignore
+			matcher.AddFile(filepath.Join(path, ".gitignore"), repoRel)
+			matcher.AddFile(filepath.Join(path, ".oliignore"), repoRel)
+			return nil
+		}
 
-			fileContents = append(fileContents, fmt.Sprintf("### %s\n```\n%s\n```", rel, string(content)))
-			totalSize += fileSize
-			count++
-		} else {
-			// Solo listar el archivo sin leer contenido
-			fileList = append(fileList, rel)
-			count++
+		// Ignorar archivos ocultos, de lock y excluidos por los patrones
+		if strings.HasPrefix(name, ".") || ignoredFiles[name] || matcher.Match(repoRel, false) {
+			return nil
 		}
 
-		return nil
+		// Verificar profundidad
+		depth := strings.Count(rel, string(os.PathSeparator))
+		if depth > maxDepth {
+			return nil
+		}
+
+		return fn(rel, path, d)
 	})
+}
 
+// projectIgnores prepara las reglas que valen para workDir antes de
+// recorrerlo: defaultIgnore, .git/info/exclude y los .gitignore de las
+// carpetas entre la raíz del repositorio y workDir. Devuelve además la ruta
+// de workDir relativa a esa raíz ("" si coinciden o no hay repositorio).
+func projectIgnores(workDir string) (*ignore.Matcher, string) {
+	matcher := ignore.New()
+	matcher.Add("", defaultIgnore)
+
+	abs, err := filepath.Abs(workDir)
 	if err != nil {
-		return ContextResult{Provider: p.Name()}, err
+		return matcher, ""
+	}
+	root := abs
+	for {
+		if _, err := os.Stat(filepath.Join(root, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			// Sin repositorio: solo cuentan los archivos dentro de workDir
+			return matcher, ""
+		}
+		root = parent
 	}
 
-	// Construir resultado
-	var sb strings.Builder
+	matcher.AddFile(filepath.Join(root, ".git", "info", "exclude"), "")
 
-	if len(fileContents) > 0 {
-		sb.WriteString("## Contenido de archivos del proyecto\n\n")
-		sb.WriteString(strings.Join(fileContents, "\n\n"))
+	prefix, _ := filepath.Rel(root, abs)
+	prefix = filepath.ToSlash(prefix)
+	if prefix == "." {
+		return matcher, ""
 	}
 
-	if len(fileList) > 0 {
-		sb.WriteString("\n\n## Otros archivos (sin contenido)\n")
-		sb.WriteString(strings.Join(fileList, "\n"))
+	dir, base := root, ""
+	for _, part := range strings.Split(prefix, "/") {
+		matcher.AddFile(filepath.Join(dir, ".gitignore"), base)
+		matcher.AddFile(filepath.Join(dir, ".oliignore"), base)
+		dir = filepath.Join(dir, part)
+		base = ignore.Join(base, part)
 	}
-
-	return ContextResult{
-		Provider: p.Name(),
-		Content:  sb.String(),
-	}, nil
+	return matcher, prefix
 }
@@ -4,14 +4,53 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
+	"unicode"
 )
 
-type GitProvider struct{}
+// Limits for diff content, so one huge change does not crowd out the rest.
+const (
+	maxDiffPerFile = 8000
+	maxDiffTotal   = 40000
+)
+
+// DiffMode selects when GitProvider includes diff content.
+type DiffMode string
+
+const (
+	// DiffAuto includes diffs when the task looks like a request to review
+	// or describe changes (see SetDiffKeywords).
+	DiffAuto   DiffMode = "auto"
+	DiffAlways DiffMode = "always"
+	DiffNever  DiffMode = "never"
+)
+
+type GitProvider struct {
+	diffMode     DiffMode
+	base         string
+	diffKeywords []string
+}
 
 func NewGitProvider() *GitProvider {
-	return &GitProvider{}
+	return &GitProvider{diffMode: DiffAuto}
+}
+
+// SetDiff configures diff content: when to include it and an optional base
+// ref (for example "main") whose changes up to HEAD are also included.
+func (p *GitProvider) SetDiff(mode DiffMode, base string) {
+	p.diffMode = mode
+	p.base = base
+}
+
+// SetDiffKeywords sets the words that trigger diffs in DiffAuto mode.
+// Matching is case-insensitive on whole words of the task, so "diff" does
+// not match "different"; a keyword of several words matches them in a row.
+func (p *GitProvider) SetDiffKeywords(keywords []string) {
+	p.diffKeywords = keywords
 }
 
 func (p *GitProvider) Name() string {
@@ -51,15 +90,157 @@ func (p *GitProvider) Gather(ctx context.Context, workDir string) (ContextResult
 		}
 	}
 
+	if p.wantsDiff(TaskFrom(ctx)) {
+		sections = append(sections, p.diffs(ctx, workDir)...)
+	}
+
 	return ContextResult{
 		Provider: p.Name(),
 		Content:  strings.Join(sections, "\n\n"),
 	}, nil
 }
 
+func (p *GitProvider) wantsDiff(task string) bool {
+	switch p.diffMode {
+	case DiffAlways:
+		return true
+	case DiffNever:
+		return false
+	}
+	if p.base != "" {
+		return true
+	}
+	words := splitWords(task)
+	for _, kw := range p.diffKeywords {
+		if containsWords(words, splitWords(kw)) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitWords returns the lowercase words of s; anything that is not a
+// letter or a digit separates words.
+func splitWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// containsWords reports whether seq appears in words as consecutive words.
+func containsWords(words, seq []string) bool {
+	if len(seq) == 0 {
+		return false
+	}
+	for i := 0; i+len(seq) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(seq)], seq) {
+			return true
+		}
+	}
+	return false
+}
+
+// diffs returns one section per changed file: staged, unstaged and, with a
+// base ref, committed since the merge base. Each file and the total are
+// capped; what is cut is noted so the model knows the diff is partial.
+func (p *GitProvider) diffs(ctx context.Context, workDir string) []string {
+	type diffKind struct {
+		label string
+		args  []string
+	}
+	kinds := []diffKind{
+		{"staged", []string{"diff", "--cached"}},
+		{"unstaged", []string{"diff"}},
+	}
+	if p.base != "" {
+		kinds = append(kinds, diffKind{p.base + "...HEAD", []string{"diff", p.base + "...HEAD"}})
+	}
+
+	var sections []string
+	var skipped []string
+	total := 0
+	for _, kind := range kinds {
+		args := append(kind.args, "--no-color", "--no-ext-diff", "--relative", "--")
+		out, err := p.runGit(ctx, workDir, args...)
+		if err != nil {
+			sections = append(sections, fmt.Sprintf("Diff %s: %v", kind.label, strings.TrimSpace(err.Error())))
+			continue
+		}
+
+		for _, file := range SplitDiff(out) {
+			text := file.Text
+			if len(text) > maxDiffPerFile {
+				text = cutAtLine(text, maxDiffPerFile)
+				text += fmt.Sprintf("\n... (diff truncado, %d bytes más)\n", len(file.Text)-len(text))
+			}
+			if total+len(text) > maxDiffTotal {
+				skipped = append(skipped, fmt.Sprintf("%s (%s)", file.Path, kind.label))
+				continue
+			}
+			total += len(text)
+			fence := CodeFence(text)
+			sections = append(sections, fmt.Sprintf("### Diff %s: %s\n%sdiff\n%s%s", kind.label, file.Path, fence, text, fence))
+		}
+	}
+
+	if len(skipped) > 0 {
+		sections = append(sections, "Diffs omitidos por tamaño: "+strings.Join(skipped, ", "))
+	}
+	return sections
+}
+
+// FileDiff is the part of a diff that concerns one file.
+type FileDiff struct {
+	Path string
+	Text string
+}
+
+// SplitDiff cuts the output of git diff at each "diff --git" header.
+func SplitDiff(out string) []FileDiff {
+	parts := strings.Split("\n"+out, "\ndiff --git ")
+	files := make([]FileDiff, 0, len(parts))
+	for _, part := range parts[1:] {
+		text := "diff --git " + part
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		header, _, _ := strings.Cut(text, "\n")
+		files = append(files, FileDiff{Path: diffPath(header), Text: text})
+	}
+	return files
+}
+
+// diffPath extracts the new path from a "diff --git a/x b/x" header.
+func diffPath(header string) string {
+	header = strings.TrimSpace(strings.TrimPrefix(header, "diff --git "))
+	if i := strings.LastIndex(header, " b/"); i >= 0 {
+		return header[i+3:]
+	}
+	return header
+}
+
+// cutAtLine returns the longest prefix of s made of whole lines within n bytes.
+func cutAtLine(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if i := strings.LastIndexByte(s[:n], '\n'); i >= 0 {
+		return s[:i+1]
+	}
+	return s[:n]
+}
+
 func (p *GitProvider) runGit(ctx context.Context, workDir string, args ...string) (string, error) {
+	return p.runGitInput(ctx, workDir, "", args...)
+}
+
+// runGitInput is runGit with input fed to git's standard input.
+func (p *GitProvider) runGitInput(ctx context.Context, workDir, input string, args ...string) (string, error) {
 	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = workDir
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -70,3 +251,76 @@ func (p *GitProvider) runGit(ctx context.Context, workDir string, args ...string
 	}
 	return stdout.String(), nil
 }
+
+// RangeDiff returns the diff for a revision range as accepted by git diff
+// ("main..feature", "main...HEAD", or a single ref compared with the
+// working tree). An empty range means uncommitted changes against HEAD.
+func (p *GitProvider) RangeDiff(ctx context.Context, workDir, revRange string) (string, error) {
+	return p.runGit(ctx, workDir, "diff", "--no-color", "--no-ext-diff", "--relative", rangeArg(revRange), "--")
+}
+
+// ChangedFiles lists the files (relative to workDir) changed in revRange
+// that still exist on its new side.
+func (p *GitProvider) ChangedFiles(ctx context.Context, workDir, revRange string) ([]string, error) {
+	out, err := p.runGit(ctx, workDir, "diff", "--name-only", "--diff-filter=d", "--relative", rangeArg(revRange), "--")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// FileAt returns the content of path (relative to workDir) at ref, or in
+// the working tree when ref is empty.
+func (p *GitProvider) FileAt(ctx context.Context, workDir, ref, path string) (string, error) {
+	if ref == "" {
+		data, err := os.ReadFile(filepath.Join(workDir, path))
+		return string(data), err
+	}
+	return p.runGit(ctx, workDir, "show", ref+":./"+filepath.ToSlash(path))
+}
+
+// RangeHead returns the revision whose files a range leads to: the part
+// after ".." or "...", or "" (the working tree) for a single ref or none.
+func RangeHead(revRange string) string {
+	if i := strings.Index(revRange, ".."); i >= 0 {
+		head := strings.TrimLeft(revRange[i:], ".")
+		if head == "" {
+			return "HEAD"
+		}
+		return head
+	}
+	return ""
+}
+
+func rangeArg(revRange string) string {
+	if revRange == "" {
+		return "HEAD"
+	}
+	return revRange
+}
+
+// CurrentBranch returns the checked-out branch, or "" outside a repository
+// or on a detached HEAD.
+func (p *GitProvider) CurrentBranch(ctx context.Context, workDir string) string {
+	out, err := p.runGit(ctx, workDir, "branch", "--show-current")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// StagedDiff returns the changes staged for the next commit.
+func (p *GitProvider) StagedDiff(ctx context.Context, workDir string) (string, error) {
+	return p.runGit(ctx, workDir, "diff", "--cached", "--no-color", "--no-ext-diff", "--")
+}
+
+// Commit records the staged changes with message and returns git's output.
+func (p *GitProvider) Commit(ctx context.Context, workDir, message string) (string, error) {
+	return p.runGitInput(ctx, workDir, message, "commit", "--file=-")
+}
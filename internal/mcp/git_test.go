@@ -0,0 +1,65 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWantsDiffMatchesWholeWords(t *testing.T) {
+	p := NewGitProvider()
+	p.SetDiffKeywords([]string{"diff", "review", "commit", "cambios", "pull request"})
+
+	tests := []struct {
+		task string
+		want bool
+	}{
+		{"show me the diff", true},
+		{"Review my changes, please", true},
+		{"¿qué cambios hice?", true},
+		{"explain the last commit.", true},
+		{"open a pull request", true},
+		{"what is different between a and b", false},
+		{"add a preview pane", false},
+		{"our commitment to stability", false},
+		{"pull the request body", false},
+	}
+	for _, tt := range tests {
+		if got := p.wantsDiff(tt.task); got != tt.want {
+			t.Errorf("wantsDiff(%q) = %v, want %v", tt.task, got, tt.want)
+		}
+	}
+}
+
+func TestDiffsFenceMarkdown(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@t", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	readme := filepath.Join(dir, "README.md")
+	os.WriteFile(readme, []byte("# Uso\n\n```\nmake\n```\n"), 0644)
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+	os.WriteFile(readme, []byte("# Uso\n\n```\nmake install\n```\n"), 0644)
+
+	sections := NewGitProvider().diffs(context.Background(), dir)
+	if len(sections) != 1 {
+		t.Fatalf("sections = %q", sections)
+	}
+	// The diff has " ```" context lines, so the section needs a longer fence
+	if !strings.Contains(sections[0], "````diff\n") || !strings.HasSuffix(sections[0], "\n````") {
+		t.Errorf("section is not fenced with ````:\n%s", sections[0])
+	}
+}
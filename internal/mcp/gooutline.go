@@ -0,0 +1,311 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// GoOutlineProvider summarises the exported API of every package in a Go
+// module: types, function signatures, interface methods and the first
+// sentence of their doc comments. It is far smaller than the sources, so
+// the model sees the whole module even when few files fit in full.
+type GoOutlineProvider struct {
+	maxDepth int
+}
+
+func NewGoOutlineProvider(maxDepth int) *GoOutlineProvider {
+	return &GoOutlineProvider{maxDepth: maxDepth}
+}
+
+func (p *GoOutlineProvider) Name() string {
+	return "go-outline"
+}
+
+// Gather returns nothing outside Go projects.
+func (p *GoOutlineProvider) Gather(ctx context.Context, workDir string) (ContextResult, error) {
+	files, err := ProjectFiles(ctx, workDir, p.maxDepth)
+	if err != nil {
+		return ContextResult{Provider: p.Name()}, err
+	}
+
+	// Group non-test sources by directory, which is a package in Go
+	dirs := make(map[string][]string)
+	for _, rel := range files {
+		if strings.HasSuffix(rel, ".go") && !strings.HasSuffix(rel, "_test.go") {
+			dir := filepath.Dir(rel)
+			dirs[dir] = append(dirs[dir], rel)
+		}
+	}
+	if len(dirs) == 0 {
+		return ContextResult{Provider: p.Name()}, nil
+	}
+
+	names := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		names = append(names, dir)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString("## Esquema de la API Go")
+	if module := modulePath(workDir); module != "" {
+		fmt.Fprintf(&sb, " (módulo %s)", module)
+	}
+	sb.WriteString("\n")
+
+	var problems []string
+	for _, dir := range names {
+		if err := ctx.Err(); err != nil {
+			return ContextResult{Provider: p.Name()}, err
+		}
+		outline, err := outlinePackage(workDir, dir, dirs[dir])
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+		if outline != "" {
+			sb.WriteString("\n")
+			sb.WriteString(outline)
+		}
+	}
+
+	return ContextResult{
+		Provider: p.Name(),
+		Content:  strings.TrimRight(sb.String(), "\n"),
+		Error:    strings.Join(problems, "; "),
+	}, nil
+}
+
+// outlinePackage renders one "### package" section. Files that fail to
+// parse are skipped and reported in the error.
+func outlinePackage(workDir, dir string, files []string) (string, error) {
+	fset := token.NewFileSet()
+	var name, doc string
+	var decls []string
+	var failed []string
+
+	sort.Strings(files)
+	for _, rel := range files {
+		f, err := parser.ParseFile(fset, filepath.Join(workDir, rel), nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			failed = append(failed, rel)
+			continue
+		}
+		if name == "" {
+			name = f.Name.Name
+		}
+		if doc == "" && f.Doc != nil {
+			doc = firstSentence(f.Doc.Text())
+		}
+		for _, decl := range f.Decls {
+			decls = append(decls, outlineDecl(fset, decl)...)
+		}
+	}
+
+	var err error
+	if len(failed) > 0 {
+		err = fmt.Errorf("no se pudo analizar %s", strings.Join(failed, ", "))
+	}
+	if name == "" {
+		return "", err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "### package %s (%s)\n", name, filepath.ToSlash(dir))
+	if doc != "" {
+		fmt.Fprintf(&sb, "// %s\n", doc)
+	}
+	for _, d := range decls {
+		sb.WriteString(d)
+		sb.WriteString("\n")
+	}
+	return sb.String(), err
+}
+
+// outlineDecl renders the exported parts of a top-level declaration.
+func outlineDecl(fset *token.FileSet, decl ast.Decl) []string {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if !d.Name.IsExported() || (d.Recv != nil && !receiverExported(d.Recv)) {
+			return nil
+		}
+		sig := *d
+		sig.Doc = nil
+		sig.Body = nil
+		return []string{withDoc(d.Doc, node(fset, &sig))}
+
+	case *ast.GenDecl:
+		var out []string
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				if !s.Name.IsExported() {
+					continue
+				}
+				doc := s.Doc
+				if doc == nil && len(d.Specs) == 1 {
+					doc = d.Doc
+				}
+				out = append(out, withDoc(doc, "type "+outlineType(fset, s)))
+			case *ast.ValueSpec:
+				var names []string
+				for _, n := range s.Names {
+					if n.IsExported() {
+						names = append(names, n.Name)
+					}
+				}
+				if len(names) == 0 {
+					continue
+				}
+				line := d.Tok.String() + " " + strings.Join(names, ", ")
+				if s.Type != nil {
+					line += " " + node(fset, s.Type)
+				}
+				doc := s.Doc
+				if doc == nil && len(d.Specs) == 1 {
+					doc = d.Doc
+				}
+				out = append(out, withDoc(doc, line))
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// outlineType renders a type spec keeping only exported struct fields and
+// interface methods.
+func outlineType(fset *token.FileSet, s *ast.TypeSpec) string {
+	// Print the spec with a placeholder type to get the name, type
+	// parameters and alias marker as written
+	spec := *s
+	spec.Doc, spec.Comment = nil, nil
+	spec.Type = ast.NewIdent("_")
+	head := strings.TrimSuffix(node(fset, &spec), " _")
+
+	switch t := s.Type.(type) {
+	case *ast.StructType:
+		var fields []string
+		for _, f := range t.Fields.List {
+			if len(f.Names) == 0 {
+				// Embedded field
+				fields = append(fields, "\t"+node(fset, f.Type))
+				continue
+			}
+			var names []string
+			for _, n := range f.Names {
+				if n.IsExported() {
+					names = append(names, n.Name)
+				}
+			}
+			if len(names) > 0 {
+				fields = append(fields, "\t"+strings.Join(names, ", ")+" "+node(fset, f.Type))
+			}
+		}
+		if len(fields) == 0 {
+			return head + " struct{ ... }"
+		}
+		return head + " struct {\n" + strings.Join(fields, "\n") + "\n}"
+
+	case *ast.InterfaceType:
+		var methods []string
+		for _, m := range t.Methods.List {
+			if len(m.Names) == 0 {
+				methods = append(methods, "\t"+node(fset, m.Type))
+				continue
+			}
+			if !m.Names[0].IsExported() {
+				continue
+			}
+			sig := strings.TrimPrefix(node(fset, m.Type), "func")
+			line := "\t" + m.Names[0].Name + sig
+			if m.Doc != nil {
+				line = "\t// " + firstSentence(m.Doc.Text()) + "\n" + line
+			}
+			methods = append(methods, line)
+		}
+		if len(methods) == 0 {
+			if len(t.Methods.List) > 0 {
+				return head + " interface{ ... }"
+			}
+			return head + " interface{}"
+		}
+		return head + " interface {\n" + strings.Join(methods, "\n") + "\n}"
+	}
+	return head + " " + node(fset, s.Type)
+}
+
+// receiverExported reports whether a method's receiver type is exported.
+func receiverExported(recv *ast.FieldList) bool {
+	if len(recv.List) == 0 {
+		return false
+	}
+	expr := recv.List[0].Type
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.IndexExpr:
+			expr = t.X
+		case *ast.IndexListExpr:
+			expr = t.X
+		case *ast.Ident:
+			return t.IsExported()
+		default:
+			return false
+		}
+	}
+}
+
+func withDoc(doc *ast.CommentGroup, text string) string {
+	if doc == nil {
+		return text
+	}
+	if sentence := firstSentence(doc.Text()); sentence != "" {
+		return "// " + sentence + "\n" + text
+	}
+	return text
+}
+
+// firstSentence returns the first sentence of a doc comment on one line.
+func firstSentence(doc string) string {
+	doc = strings.Join(strings.Fields(doc), " ")
+	if i := strings.Index(doc, ". "); i >= 0 {
+		return doc[:i+1]
+	}
+	return doc
+}
+
+func node(fset *token.FileSet, n any) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, n); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// modulePath reads the module path from workDir/go.mod, if any.
+func modulePath(workDir string) string {
+	f, err := os.Open(filepath.Join(workDir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGoOutline(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/shop\n\ngo 1.22\n",
+		"cart/cart.go": `// Package cart keeps the items a customer is buying. More text here.
+package cart
+
+// Cart holds items. It is not safe for concurrent use.
+type Cart struct {
+	Items []Item
+	owner string
+	Total, tax int
+}
+
+// Store persists carts.
+type Store interface {
+	// Save writes the cart.
+	Save(c *Cart) error
+	load(id string) (*Cart, error)
+	Named
+}
+
+type hidden interface {
+	Run()
+}
+
+type sealed interface {
+	seal()
+}
+
+type Named interface{ name() string }
+
+// MaxItems limits a cart.
+const MaxItems = 50
+
+var (
+	ErrFull = errFull()
+	cache   map[string]*Cart
+)
+
+// Add appends an item. Returns false when full.
+func (c *Cart) Add(it Item) bool { return true }
+
+func (h hidden2) Exported() {}
+
+func helper() {}
+
+func errFull() error { return nil }
+
+type hidden2 struct{}
+`,
+		"cart/item.go":      "package cart\n\ntype Item struct{ SKU string }\n",
+		"cart/cart_test.go": "package cart\n\nfunc TestX() {}\n",
+		"broken/b.go":       "package broken\n\nfunc (\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := NewGoOutlineProvider(4).Gather(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "## Esquema de la API Go (módulo example.com/shop)\n" +
+		"\n### package cart (cart)\n" +
+		"// Package cart keeps the items a customer is buying.\n" +
+		"// Cart holds items.\n" +
+		"type Cart struct {\n\tItems []Item\n\tTotal int\n}\n" +
+		"// Store persists carts.\n" +
+		"type Store interface {\n\t// Save writes the cart.\n\tSave(c *Cart) error\n\tNamed\n}\n" +
+		"type Named interface{ ... }\n" +
+		"// MaxItems limits a cart.\n" +
+		"const MaxItems\n" +
+		"var ErrFull\n" +
+		"// Add appends an item.\n" +
+		"func (c *Cart) Add(it Item) bool\n" +
+		"type Item struct {\n\tSKU string\n}"
+	if got.Content != want {
+		t.Errorf("Content =\n%s\n\nwant\n%s", got.Content, want)
+	}
+	if got.Error != "no se pudo analizar broken/b.go" {
+		t.Errorf("Error = %q", got.Error)
+	}
+}
@@ -0,0 +1,93 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"ollama-cli/internal/index"
+	"ollama-cli/internal/llm"
+)
+
+// IndexProvider retrieves the chunks of the project's embeddings index
+// (see "oli index build") most similar to the task.
+type IndexProvider struct {
+	embedder llm.Embedder
+	topK     int
+
+	// The index is decoded once and reused until its file changes
+	cached    *index.Index
+	cachedDir string
+	cachedMod time.Time
+}
+
+func NewIndexProvider(embedder llm.Embedder, topK int) *IndexProvider {
+	return &IndexProvider{embedder: embedder, topK: topK}
+}
+
+func (p *IndexProvider) Name() string {
+	return "index"
+}
+
+// Gather returns nothing when there is no task or the project has no index,
+// so it can stay registered in projects that never built one.
+func (p *IndexProvider) Gather(ctx context.Context, workDir string) (ContextResult, error) {
+	task := TaskFrom(ctx)
+	if task == "" {
+		return ContextResult{Provider: p.Name()}, nil
+	}
+
+	ix, err := p.load(workDir)
+	if errors.Is(err, index.ErrNotFound) {
+		return ContextResult{Provider: p.Name()}, nil
+	}
+	if err != nil {
+		return ContextResult{Provider: p.Name()}, err
+	}
+
+	// The query must be embedded with the same model as the index
+	vectors, err := p.embedder.Embed(ctx, ix.Model, []string{task})
+	if err != nil {
+		return ContextResult{Provider: p.Name()}, fmt.Errorf("embed task: %w", err)
+	}
+
+	var sections []string
+	for _, r := range ix.Search(vectors[0], p.topK) {
+		fence := CodeFence(r.Text)
+		sections = append(sections, fmt.Sprintf("### %s:%d-%d\n%s\n%s\n%s", r.Path, r.Start, r.End, fence, r.Text, fence))
+	}
+	if len(sections) == 0 {
+		return ContextResult{Provider: p.Name()}, nil
+	}
+
+	return ContextResult{
+		Provider: p.Name(),
+		Content:  "## Fragmentos relevantes (índice semántico)\n\n" + strings.Join(sections, "\n\n"),
+	}, nil
+}
+
+// load returns the index of workDir, decoding it again only when the file
+// changed (for example after "oli index build" in another terminal).
+func (p *IndexProvider) load(workDir string) (*index.Index, error) {
+	info, err := os.Stat(index.Path(workDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, index.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.cached != nil && p.cachedDir == workDir && info.ModTime().Equal(p.cachedMod) {
+		return p.cached, nil
+	}
+
+	ix, err := index.Load(workDir)
+	if err != nil {
+		return nil, err
+	}
+	p.cached, p.cachedDir, p.cachedMod = ix, workDir, info.ModTime()
+	return ix, nil
+}
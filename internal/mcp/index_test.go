@@ -0,0 +1,42 @@
+package mcp
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"ollama-cli/internal/index"
+)
+
+func TestIndexProviderLoadsOnce(t *testing.T) {
+	root := t.TempDir()
+	p := NewIndexProvider(nil, 4)
+
+	if _, err := p.load(root); !errors.Is(err, index.ErrNotFound) {
+		t.Fatalf("load() without index = %v, want ErrNotFound", err)
+	}
+
+	if err := index.New("m1").Save(root); err != nil {
+		t.Fatal(err)
+	}
+	first, err := p.load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := p.load(root)
+	if again != first {
+		t.Error("index decoded again without changes")
+	}
+
+	// A rebuild is picked up
+	if err := index.New("m2").Save(root); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Second)
+	os.Chtimes(index.Path(root), later, later)
+	rebuilt, err := p.load(root)
+	if err != nil || rebuilt.Model != "m2" {
+		t.Errorf("load() after rebuild = %+v, %v", rebuilt, err)
+	}
+}
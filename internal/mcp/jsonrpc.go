@@ -0,0 +1,243 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// RPCError is a JSON-RPC error object. Handlers may return one to choose
+// the code sent to the peer.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+// ErrConnClosed is returned by Call when the peer goes away.
+var ErrConnClosed = errors.New("connection closed")
+
+// Handler answers requests and notifications from the peer. The result of
+// a notification is ignored.
+type Handler func(ctx context.Context, method string, params json.RawMessage) (any, error)
+
+// message is any JSON-RPC 2.0 message: request, notification or response.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// Conn is a JSON-RPC 2.0 connection over newline-delimited messages, as
+// used by the MCP stdio transport. Both sides can send requests.
+type Conn struct {
+	r       *bufio.Reader
+	w       io.Writer
+	handler Handler
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[string]chan message
+	closed  bool
+}
+
+// NewConn wraps r and w. handler may be nil, in which case every request
+// from the peer is answered with "method not found".
+func NewConn(r io.Reader, w io.Writer, handler Handler) *Conn {
+	return &Conn{
+		r:       bufio.NewReader(r),
+		w:       w,
+		handler: handler,
+		pending: make(map[string]chan message),
+	}
+}
+
+// Serve reads messages until the reader is exhausted or ctx is done,
+// dispatching responses to pending calls and requests to the handler.
+// Requests are handled one at a time, in order.
+func (c *Conn) Serve(ctx context.Context) error {
+	defer c.shutdown()
+
+	for {
+		line, err := c.r.ReadBytes('\n')
+		if len(line) > 0 {
+			c.dispatch(ctx, line)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *Conn) dispatch(ctx context.Context, line []byte) {
+	var msg message
+	if err := json.Unmarshal(line, &msg); err != nil {
+		if len(bytes.TrimSpace(line)) > 0 {
+			c.send(message{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &RPCError{Code: CodeParseError, Message: err.Error()}})
+		}
+		return
+	}
+
+	if msg.Method == "" {
+		// Response to one of our calls
+		c.mu.Lock()
+		ch, ok := c.pending[string(msg.ID)]
+		delete(c.pending, string(msg.ID))
+		c.mu.Unlock()
+		if ok {
+			ch <- msg
+		}
+		return
+	}
+
+	var result any
+	var err error
+	if c.handler != nil {
+		result, err = c.handler(ctx, msg.Method, msg.Params)
+	} else {
+		err = &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+
+	if len(msg.ID) == 0 {
+		return // Notification: no reply
+	}
+	reply := message{JSONRPC: "2.0", ID: msg.ID}
+	if err != nil {
+		var rpcErr *RPCError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &RPCError{Code: CodeInternalError, Message: err.Error()}
+		}
+		reply.Error = rpcErr
+	} else {
+		if result == nil {
+			result = struct{}{}
+		}
+		data, merr := json.Marshal(result)
+		if merr != nil {
+			reply.Error = &RPCError{Code: CodeInternalError, Message: merr.Error()}
+		} else {
+			reply.Result = data
+		}
+	}
+	c.send(reply)
+}
+
+// Call sends a request and decodes the result into result (if not nil).
+// Serve must be running to receive the response.
+func (c *Conn) Call(ctx context.Context, method string, params, result any) error {
+	raw, err := marshalParams(params)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrConnClosed
+	}
+	c.nextID++
+	id := strconv.FormatInt(c.nextID, 10)
+	ch := make(chan message, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := c.send(message{JSONRPC: "2.0", ID: json.RawMessage(id), Method: method, Params: raw}); err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return ctx.Err()
+	case reply, ok := <-ch:
+		if !ok {
+			return ErrConnClosed
+		}
+		if reply.Error != nil {
+			return reply.Error
+		}
+		if result != nil && len(reply.Result) > 0 {
+			if err := json.Unmarshal(reply.Result, result); err != nil {
+				return fmt.Errorf("decode %s result: %w", method, err)
+			}
+		}
+		return nil
+	}
+}
+
+// Notify sends a notification, which gets no response.
+func (c *Conn) Notify(method string, params any) error {
+	raw, err := marshalParams(params)
+	if err != nil {
+		return err
+	}
+	return c.send(message{JSONRPC: "2.0", Method: method, Params: raw})
+}
+
+func (c *Conn) send(msg message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err = c.w.Write(append(data, '\n'))
+	return err
+}
+
+// shutdown fails every pending call once the peer is gone.
+func (c *Conn) shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+}
+
+func marshalParams(params any) (json.RawMessage, error) {
+	if params == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("marshal params: %w", err)
+	}
+	return data, nil
+}
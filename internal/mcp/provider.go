@@ -24,3 +24,9 @@ type ContextResult struct {
 	// Error is set if the provider failed (Content may still be partial).
 	Error string
 }
+
+// SizeLimited is implemented by providers that can cap the size of their
+// output, so they read no more than the prompt budget can hold.
+type SizeLimited interface {
+	SetMaxSize(bytes int)
+}
@@ -0,0 +1,289 @@
+package mcp
+
+import (
+	"context"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Pesos de cada señal de relevancia. La mención explícita de una ruta
+// domina; BM25 y la actividad reciente en git ordenan el resto.
+const (
+	weightPathMention = 100.0 // La tarea menciona la ruta relativa
+	weightBaseMention = 60.0  // La tarea menciona el nombre del archivo
+	weightStemMention = 20.0  // La tarea menciona el nombre sin extensión
+	weightDirMention  = 5.0   // La tarea menciona una carpeta de la ruta
+	weightIdentifier  = 15.0  // El archivo contiene un identificador citado
+	weightBM25        = 5.0
+	weightUncommitted = 12.0 // Cambios sin confirmar
+	weightRecent      = 10.0 // Máximo para el archivo tocado más recientemente
+)
+
+// Parámetros habituales de BM25
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// recentCommits es cuántos commits se revisan para medir la actividad reciente
+const recentCommits = 100
+
+// candidate es un archivo que podría incluirse en el contexto
+type candidate struct {
+	rel      string // Ruta relativa a workDir
+	path     string
+	size     int
+	readable bool
+	content  string // Cargado solo si es legible y cabe en maxFileSize
+	loaded   bool
+	score    float64
+}
+
+// query es la tarea descompuesta en las señales que se buscan en los archivos
+type query struct {
+	paths  []string        // Palabras con forma de ruta o nombre de archivo
+	idents []string        // Identificadores tal cual (FooBar, foo_bar, pkg.Func)
+	terms  map[string]bool // Términos en minúsculas para BM25
+}
+
+var (
+	wordPattern  = regexp.MustCompile(`[\p{L}_][\p{L}\p{N}_]*`)
+	identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+)
+
+// stopwords son palabras frecuentes en las tareas que no aportan al ranking
+var stopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "this": true,
+	"that": true, "what": true, "how": true, "does": true, "why": true,
+	"from": true, "into": true, "are": true, "can": true, "you": true,
+	"please": true, "file": true, "code": true,
+	"que": true, "qué": true, "los": true, "las": true, "del": true,
+	"por": true, "para": true, "con": true, "una": true, "uno": true,
+	"como": true, "cómo": true, "este": true, "esta": true, "esto": true,
+	"hay": true, "son": true, "pero": true, "sus": true, "muy": true,
+	"más": true, "cuál": true, "dónde": true, "archivo": true, "código": true,
+}
+
+func parseQuery(task string) query {
+	q := query{terms: make(map[string]bool)}
+
+	for _, field := range strings.Fields(task) {
+		word := strings.Trim(field, "`'\"()[]{}<>,;:!?¿¡")
+		word = strings.TrimSuffix(word, ".")
+		if word == "" {
+			continue
+		}
+		if strings.Contains(word, "/") || looksLikeFile(word) {
+			q.paths = append(q.paths, strings.TrimPrefix(word, "./"))
+			continue
+		}
+		if identPattern.MatchString(word) && isIdentifier(word) {
+			q.idents = append(q.idents, word)
+			// pkg.Func: el selector suele aparecer solo en la definición
+			if i := strings.LastIndexByte(word, '.'); i >= 0 && isIdentifier(word[i+1:]) {
+				q.idents = append(q.idents, word[i+1:])
+			}
+		}
+	}
+
+	for _, term := range tokenize(task) {
+		if len([]rune(term)) >= 3 && !stopwords[term] {
+			q.terms[term] = true
+		}
+	}
+	return q
+}
+
+// looksLikeFile reconoce nombres como main.go o README.md, que también
+// encajan con la forma pkg.Func.
+func looksLikeFile(word string) bool {
+	ext := path.Ext(word)
+	return ext != "" && (readableExtensions[ext] || readableExtensions[word])
+}
+
+// isIdentifier distingue identificadores de código (CamelCase interno,
+// guiones bajos, selectores) de palabras normales.
+func isIdentifier(word string) bool {
+	if len(word) < 3 {
+		return false
+	}
+	if strings.ContainsAny(word, "_.") {
+		return true
+	}
+	for _, r := range word[1:] {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenize separa el texto en palabras en minúsculas. Los identificadores
+// compuestos aportan además sus partes (FilesystemProvider → filesystem,
+// provider) para que coincidan con tareas escritas en lenguaje natural.
+func tokenize(text string) []string {
+	var tokens []string
+	for _, word := range wordPattern.FindAllString(text, -1) {
+		lower := strings.ToLower(word)
+		tokens = append(tokens, lower)
+		if parts := splitIdentifier(word); len(parts) > 1 {
+			for _, part := range parts {
+				tokens = append(tokens, strings.ToLower(part))
+			}
+		}
+	}
+	return tokens
+}
+
+func splitIdentifier(word string) []string {
+	var parts []string
+	for _, chunk := range strings.Split(word, "_") {
+		runes := []rune(chunk)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			// Corte en aB y en la última mayúscula de ABc (HTTPServer → HTTP, Server)
+			if unicode.IsUpper(runes[i]) && (unicode.IsLower(runes[i-1]) ||
+				i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])) {
+				parts = append(parts, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			parts = append(parts, string(runes[start:]))
+		}
+	}
+	return parts
+}
+
+// rankCandidates ordena files de mayor a menor relevancia para task.
+// Sin tarea ni historial de git se conserva el orden original.
+func rankCandidates(ctx context.Context, workDir, task string, files []*candidate) {
+	q := parseQuery(task)
+	recency := gitRecency(ctx, workDir)
+
+	for _, c := range files {
+		c.score = mentionScore(q, c.rel) + recency[c.rel]
+		for _, ident := range q.idents {
+			if c.loaded && strings.Contains(c.content, ident) {
+				c.score += weightIdentifier
+			}
+		}
+	}
+	scoreBM25(q, files)
+
+	sort.SliceStable(files, func(a, b int) bool {
+		return files[a].score > files[b].score
+	})
+}
+
+func mentionScore(q query, rel string) float64 {
+	rel = strings.ReplaceAll(rel, "\\", "/")
+	base := path.Base(rel)
+	stem := strings.ToLower(strings.TrimSuffix(base, path.Ext(base)))
+
+	var score float64
+	for _, p := range q.paths {
+		switch {
+		// Una ruta con carpetas puede ser relativa a un subdirectorio
+		case rel == p || strings.Contains(p, "/") && strings.HasSuffix(rel, "/"+p):
+			score += weightPathMention
+		case base == p:
+			score += weightBaseMention
+		}
+	}
+	if q.terms[stem] {
+		score += weightStemMention
+	}
+	for _, dir := range strings.Split(path.Dir(rel), "/") {
+		if q.terms[strings.ToLower(dir)] {
+			score += weightDirMention
+		}
+	}
+	return score
+}
+
+// scoreBM25 suma a cada archivo su puntuación BM25 sobre el contenido y la
+// ruta para los términos de la tarea.
+func scoreBM25(q query, files []*candidate) {
+	if len(q.terms) == 0 || len(files) == 0 {
+		return
+	}
+
+	freqs := make([]map[string]int, len(files))
+	lengths := make([]int, len(files))
+	df := make(map[string]int)
+	total := 0
+	for i, c := range files {
+		freqs[i] = make(map[string]int)
+		tokens := tokenize(c.rel)
+		if c.loaded {
+			tokens = append(tokens, tokenize(c.content)...)
+		}
+		for _, t := range tokens {
+			if q.terms[t] {
+				freqs[i][t]++
+			}
+		}
+		for t := range freqs[i] {
+			df[t]++
+		}
+		lengths[i] = len(tokens)
+		total += len(tokens)
+	}
+	avg := float64(total) / float64(len(files))
+	if avg == 0 {
+		return
+	}
+
+	n := float64(len(files))
+	for i, c := range files {
+		var score float64
+		for t, tf := range freqs[i] {
+			idf := math.Log(1 + (n-float64(df[t])+0.5)/(float64(df[t])+0.5))
+			f := float64(tf)
+			score += idf * f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*float64(lengths[i])/avg))
+		}
+		c.score += weightBM25 * score
+	}
+}
+
+// gitRecency puntúa los archivos tocados en los últimos commits (más cuanto
+// más reciente) y suma un extra a los que tienen cambios sin confirmar.
+// Fuera de un repositorio devuelve un mapa vacío.
+func gitRecency(ctx context.Context, workDir string) map[string]float64 {
+	scores := make(map[string]float64)
+	git := NewGitProvider()
+
+	logOut, err := git.runGit(ctx, workDir, "log", "-n", strconv.Itoa(recentCommits), "--name-only", "--relative", "--format=")
+	if err != nil {
+		return scores
+	}
+	var order []string
+	for _, line := range strings.Split(logOut, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			if _, seen := scores[line]; !seen {
+				scores[line] = 0
+				order = append(order, line)
+			}
+		}
+	}
+	for i, rel := range order {
+		scores[rel] = weightRecent * (1 - float64(i)/float64(len(order)))
+	}
+
+	// Se suma a la actividad reciente: un archivo modificado que además se
+	// tocó en los últimos commits es más relevante que uno solo modificado
+	if diff, err := git.runGit(ctx, workDir, "diff", "--name-only", "--relative", "HEAD"); err == nil {
+		for _, line := range strings.Split(diff, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				scores[line] += weightUncommitted
+			}
+		}
+	}
+	return scores
+}
@@ -0,0 +1,144 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	q := parseQuery("¿Por qué falla `internal/mcp/rank.go` al llamar a parseQuery y config.Load desde main.go?")
+
+	if want := []string{"internal/mcp/rank.go", "main.go"}; !reflect.DeepEqual(q.paths, want) {
+		t.Errorf("paths = %q, want %q", q.paths, want)
+	}
+	if want := []string{"parseQuery", "config.Load"}; !reflect.DeepEqual(q.idents, want) {
+		t.Errorf("idents = %q, want %q", q.idents, want)
+	}
+	for _, term := range []string{"falla", "llamar", "parse", "query", "parsequery", "config", "load", "desde", "rank"} {
+		if !q.terms[term] {
+			t.Errorf("term %q missing", term)
+		}
+	}
+	for _, term := range []string{"por", "qué", "al"} {
+		if q.terms[term] {
+			t.Errorf("stopword or short term %q kept", term)
+		}
+	}
+}
+
+func TestMentionScore(t *testing.T) {
+	q := parseQuery("revisa internal/cli/app.go, README.md y el paquete config")
+	tests := []struct {
+		rel  string
+		want float64
+	}{
+		// The path words are also terms: "app" matches the stem, "internal" and "cli" the folders
+		{"internal/cli/app.go", weightPathMention + weightStemMention + 2*weightDirMention},
+		{"README.md", weightPathMention + weightStemMention},
+		{"docs/README.md", weightBaseMention + weightStemMention},
+		{"internal/config/load.go", 2 * weightDirMention},
+		{"cmd/config.go", weightStemMention},
+		{"pkg/mcp/git.go", 0},
+	}
+	for _, tt := range tests {
+		if got := mentionScore(q, tt.rel); got != tt.want {
+			t.Errorf("mentionScore(%q) = %.1f, want %.1f", tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestRankCandidates(t *testing.T) {
+	file := func(rel, content string) *candidate {
+		return &candidate{rel: rel, content: content, loaded: true, readable: true}
+	}
+	tests := []struct {
+		name  string
+		task  string
+		files []*candidate
+		want  []string
+	}{
+		{
+			"explicit path first, then identifier, then words",
+			"¿Qué hace ParseConfig en internal/app/run.go con el caché?",
+			[]*candidate{
+				file("README.md", "Proyecto de ejemplo"),
+				file("internal/cache/cache.go", "package cache // caché de resultados"),
+				file("internal/config/parse.go", "func ParseConfig() {}"),
+				file("internal/app/run.go", "package app"),
+			},
+			[]string{"internal/app/run.go", "internal/config/parse.go", "internal/cache/cache.go", "README.md"},
+		},
+		{
+			"file name beats content",
+			"explica server.go",
+			[]*candidate{
+				file("client.go", "server server server"),
+				file("internal/mcp/server.go", "package mcp"),
+			},
+			[]string{"internal/mcp/server.go", "client.go"},
+		},
+		{
+			"no task keeps the order",
+			"",
+			[]*candidate{file("b.go", "b"), file("a.go", "a"), file("c.go", "c")},
+			[]string{"b.go", "a.go", "c.go"},
+		},
+	}
+	dir := t.TempDir() // Not a git repository: no recency
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rankCandidates(context.Background(), dir, tt.task, tt.files)
+			var got []string
+			for _, c := range tt.files {
+				got = append(got, c.rel)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitRecencyAddsUncommitted(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@t", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("old.go", "package a")
+	write("both.go", "package a")
+	write("dirty.go", "package a")
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+	write("both.go", "package a // v2")
+	git("commit", "-q", "-am", "second")
+	// both.go was committed recently and is also modified; dirty.go only modified
+	write("both.go", "package a // v3")
+	write("dirty.go", "package a // v2")
+
+	scores := gitRecency(context.Background(), dir)
+	if scores["both.go"] <= scores["dirty.go"] {
+		t.Errorf("both.go = %.1f, dirty.go = %.1f: recent and modified should rank higher", scores["both.go"], scores["dirty.go"])
+	}
+	if scores["dirty.go"] < weightUncommitted {
+		t.Errorf("dirty.go = %.1f, want at least %.1f", scores["dirty.go"], weightUncommitted)
+	}
+}
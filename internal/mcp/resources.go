@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Limits for resources read from MCP servers.
+const (
+	maxResources     = 20
+	maxResourceSize  = 50000
+	maxResourceTotal = 200000
+)
+
+// ResourceProvider exposes the text resources of an MCP server as context.
+type ResourceProvider struct {
+	client  *Client
+	uris    []string
+	maxSize int
+}
+
+// NewResourceProvider reads the given resource URIs from client, or every
+// listed resource (up to maxResources) when uris is empty.
+func NewResourceProvider(client *Client, uris []string) *ResourceProvider {
+	return &ResourceProvider{client: client, uris: uris, maxSize: maxResourceTotal}
+}
+
+func (p *ResourceProvider) Name() string {
+	return "mcp:" + p.client.Name()
+}
+
+// SetMaxSize caps the total size of the resources read.
+func (p *ResourceProvider) SetMaxSize(bytes int) {
+	p.maxSize = min(max(bytes, 0), maxResourceTotal)
+}
+
+func (p *ResourceProvider) Gather(ctx context.Context, workDir string) (ContextResult, error) {
+	uris := p.uris
+	names := make(map[string]string)
+	if len(uris) == 0 {
+		resources, err := p.client.ListResources(ctx)
+		if err != nil {
+			return ContextResult{Provider: p.Name()}, err
+		}
+		for _, r := range resources {
+			uris = append(uris, r.URI)
+			names[r.URI] = r.Name
+		}
+	}
+
+	var sections, skipped []string
+	total := 0
+	for i, uri := range uris {
+		if i >= maxResources {
+			skipped = append(skipped, uris[i:]...)
+			break
+		}
+		contents, err := p.client.ReadResource(ctx, uri)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s (error: %v)", uri, err))
+			continue
+		}
+		for _, c := range contents {
+			if c.Text == "" {
+				continue // Binary content is of no use to the model
+			}
+			if len(c.Text) > maxResourceSize || total+len(c.Text) > p.maxSize {
+				skipped = append(skipped, c.URI)
+				continue
+			}
+			total += len(c.Text)
+
+			title := c.URI
+			if name := names[c.URI]; name != "" && name != c.URI {
+				title = fmt.Sprintf("%s (%s)", name, c.URI)
+			}
+			fence := CodeFence(c.Text)
+			sections = append(sections, fmt.Sprintf("### %s\n%s\n%s\n%s", title, fence, c.Text, fence))
+		}
+	}
+
+	content := strings.Join(sections, "\n\n")
+	if len(skipped) > 0 {
+		content += "\n\nRecursos omitidos: " + strings.Join(skipped, ", ")
+	}
+	return ContextResult{Provider: p.Name(), Content: strings.TrimSpace(content)}, nil
+}
@@ -0,0 +1,157 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// resourceScheme prefixes the URIs of the context resources served by oli:
+// oli://context/<provider>, optionally with ?task=... to rank for a task.
+const resourceScheme = "oli://context/"
+
+// ServerTool is a tool offered by Server. Run returns the text shown to the
+// client; an error is reported as a failed tool result, not as a protocol
+// error.
+type ServerTool struct {
+	Info ToolInfo
+	Run  func(ctx context.Context, args map[string]any) (string, error)
+}
+
+// Server exposes context providers as resources and a set of tools to MCP
+// clients.
+type Server struct {
+	workDir      string
+	providers    []ContextProvider
+	tools        []ServerTool
+	instructions string
+}
+
+// NewServer creates a server gathering context from workDir.
+func NewServer(workDir string, providers []ContextProvider, tools []ServerTool) *Server {
+	return &Server{workDir: workDir, providers: providers, tools: tools}
+}
+
+// SetInstructions sets the usage hints sent to clients on initialize.
+func (s *Server) SetInstructions(text string) {
+	s.instructions = text
+}
+
+// Serve answers requests read from r on w until r is exhausted or ctx is
+// done.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	return NewConn(r, w, s.handle).Serve(ctx)
+}
+
+func (s *Server) handle(ctx context.Context, method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "initialize":
+		return initializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities: map[string]any{
+				"resources": map[string]any{},
+				"tools":     map[string]any{},
+			},
+			ServerInfo:   Implementation{Name: "oli", Version: "1.0"},
+			Instructions: s.instructions,
+		}, nil
+	case "notifications/initialized", "notifications/cancelled", "ping":
+		return nil, nil
+	case "resources/list":
+		return s.listResources(), nil
+	case "resources/read":
+		var p struct {
+			URI string `json:"uri"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.readResource(ctx, p.URI)
+	case "tools/list":
+		infos := make([]ToolInfo, len(s.tools))
+		for i, t := range s.tools {
+			infos[i] = t.Info
+		}
+		return map[string]any{"tools": infos}, nil
+	case "tools/call":
+		var p struct {
+			Name      string         `json:"name"`
+			Arguments map[string]any `json:"arguments"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.callTool(ctx, p.Name, p.Arguments)
+	}
+	return nil, &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + method}
+}
+
+func (s *Server) listResources() map[string]any {
+	resources := make([]ResourceInfo, 0, len(s.providers))
+	for _, p := range s.providers {
+		resources = append(resources, ResourceInfo{
+			URI:         resourceScheme + p.Name(),
+			Name:        p.Name(),
+			Description: "Contexto del proveedor " + p.Name() + "; agrega ?task=... para priorizar lo relevante a una tarea",
+			MimeType:    "text/markdown",
+		})
+	}
+	return map[string]any{"resources": resources}
+}
+
+func (s *Server) readResource(ctx context.Context, uri string) (any, error) {
+	u, err := url.Parse(uri)
+	if err != nil || !strings.HasPrefix(uri, resourceScheme) {
+		return nil, &RPCError{Code: CodeInvalidParams, Message: "unknown resource: " + uri}
+	}
+	name := strings.TrimPrefix(u.Host+u.Path, "context/")
+
+	for _, p := range s.providers {
+		if p.Name() != name {
+			continue
+		}
+		result, err := p.Gather(WithTask(ctx, u.Query().Get("task")), s.workDir)
+		if err != nil {
+			return nil, err
+		}
+		text := result.Content
+		if result.Error != "" {
+			text = strings.TrimSpace(text + "\n\n(" + result.Error + ")")
+		}
+		return map[string]any{"contents": []ResourceContents{{
+			URI:      uri,
+			MimeType: "text/markdown",
+			Text:     text,
+		}}}, nil
+	}
+	return nil, &RPCError{Code: CodeInvalidParams, Message: "unknown resource: " + uri}
+}
+
+func (s *Server) callTool(ctx context.Context, name string, args map[string]any) (any, error) {
+	for _, t := range s.tools {
+		if t.Info.Name != name {
+			continue
+		}
+		text, err := t.Run(ctx, args)
+		if err != nil {
+			return ToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+		}
+		if text == "" {
+			text = "(sin salida)"
+		}
+		return ToolResult{Content: []Content{{Type: "text", Text: text}}}, nil
+	}
+	return nil, &RPCError{Code: CodeInvalidParams, Message: "unknown tool: " + name}
+}
+
+func decodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return &RPCError{Code: CodeInvalidParams, Message: "missing params"}
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &RPCError{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// echoProvider answers with the task it was asked for and the workDir.
+type echoProvider struct{}
+
+func (echoProvider) Name() string { return "echo" }
+
+func (echoProvider) Gather(ctx context.Context, workDir string) (ContextResult, error) {
+	return ContextResult{
+		Provider: "echo",
+		Content:  fmt.Sprintf("task=%q dir=%s", TaskFrom(ctx), workDir),
+		Error:    "parcial",
+	}, nil
+}
+
+// serve connects a client Conn to a Server over a pair of pipes.
+func serve(t *testing.T, s *Server) *Conn {
+	t.Helper()
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	go func() {
+		s.Serve(context.Background(), serverR, serverW)
+		serverW.Close()
+	}()
+
+	conn := NewConn(clientR, clientW, nil)
+	go conn.Serve(context.Background())
+	t.Cleanup(func() { clientW.Close() })
+	return conn
+}
+
+func testServer() *Server {
+	s := NewServer("/proyecto", []ContextProvider{echoProvider{}}, []ServerTool{
+		{
+			Info: ToolInfo{Name: "greet", InputSchema: map[string]any{"type": "object"}},
+			Run: func(ctx context.Context, args map[string]any) (string, error) {
+				name, _ := args["name"].(string)
+				if name == "" {
+					return "", errors.New("falta name")
+				}
+				return "hola " + name, nil
+			},
+		},
+		{
+			Info: ToolInfo{Name: "quiet"},
+			Run:  func(ctx context.Context, args map[string]any) (string, error) { return "", nil },
+		},
+	})
+	s.SetInstructions("lee oli://context/echo")
+	return s
+}
+
+func TestServerInitializeAndList(t *testing.T) {
+	conn := serve(t, testServer())
+	ctx := context.Background()
+
+	var info initializeResult
+	if err := conn.Call(ctx, "initialize", map[string]any{"protocolVersion": ProtocolVersion}, &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.ServerInfo.Name != "oli" || info.Instructions != "lee oli://context/echo" || info.ProtocolVersion != ProtocolVersion {
+		t.Errorf("initialize = %+v", info)
+	}
+	if _, ok := info.Capabilities["resources"]; !ok {
+		t.Errorf("capabilities = %v", info.Capabilities)
+	}
+	if err := conn.Notify("notifications/initialized", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var resources struct {
+		Resources []ResourceInfo `json:"resources"`
+	}
+	if err := conn.Call(ctx, "resources/list", nil, &resources); err != nil {
+		t.Fatal(err)
+	}
+	if len(resources.Resources) != 1 || resources.Resources[0].URI != "oli://context/echo" {
+		t.Errorf("resources = %+v", resources.Resources)
+	}
+
+	var tools struct {
+		Tools []ToolInfo `json:"tools"`
+	}
+	if err := conn.Call(ctx, "tools/list", nil, &tools); err != nil {
+		t.Fatal(err)
+	}
+	if len(tools.Tools) != 2 || tools.Tools[0].Name != "greet" {
+		t.Errorf("tools = %+v", tools.Tools)
+	}
+
+	if err := conn.Call(ctx, "ping", nil, nil); err != nil {
+		t.Errorf("ping: %v", err)
+	}
+	var rpcErr *RPCError
+	if err := conn.Call(ctx, "prompts/list", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != CodeMethodNotFound {
+		t.Errorf("prompts/list: err = %v, want method not found", err)
+	}
+}
+
+func TestServerReadResource(t *testing.T) {
+	conn := serve(t, testServer())
+	ctx := context.Background()
+
+	read := func(uri string) ([]ResourceContents, error) {
+		var result struct {
+			Contents []ResourceContents `json:"contents"`
+		}
+		err := conn.Call(ctx, "resources/read", map[string]string{"uri": uri}, &result)
+		return result.Contents, err
+	}
+
+	contents, err := read("oli://context/echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ResourceContents{{URI: "oli://context/echo", MimeType: "text/markdown", Text: "task=\"\" dir=/proyecto\n\n(parcial)"}}
+	if !reflect.DeepEqual(contents, want) {
+		t.Errorf("contents = %+v, want %+v", contents, want)
+	}
+
+	contents, err = read("oli://context/echo?task=arregla%20el%20login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contents) != 1 || contents[0].Text != "task=\"arregla el login\" dir=/proyecto\n\n(parcial)" {
+		t.Errorf("with task: contents = %+v", contents)
+	}
+
+	for _, uri := range []string{"oli://context/missing", "file:///etc/passwd", "%%"} {
+		_, err := read(uri)
+		var rpcErr *RPCError
+		if !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidParams {
+			t.Errorf("read(%q): err = %v, want invalid params", uri, err)
+		}
+	}
+
+	var rpcErr *RPCError
+	if err := conn.Call(ctx, "resources/read", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidParams {
+		t.Errorf("read without params: err = %v", err)
+	}
+}
+
+func TestServerCallTool(t *testing.T) {
+	conn := serve(t, testServer())
+	ctx := context.Background()
+
+	call := func(name string, args map[string]any) (ToolResult, error) {
+		var result ToolResult
+		err := conn.Call(ctx, "tools/call", map[string]any{"name": name, "arguments": args}, &result)
+		return result, err
+	}
+
+	result, err := call("greet", map[string]any{"name": "Ana"})
+	if err != nil || result.IsError || result.Text() != "hola Ana" {
+		t.Errorf("greet = %+v, %v", result, err)
+	}
+
+	// A failing tool is a result with IsError, not a protocol error
+	result, err = call("greet", nil)
+	if err != nil || !result.IsError || result.Text() != "falta name" {
+		t.Errorf("greet without name = %+v, %v", result, err)
+	}
+
+	result, err = call("quiet", nil)
+	if err != nil || result.Text() != "(sin salida)" {
+		t.Errorf("quiet = %+v, %v", result, err)
+	}
+
+	_, err = call("missing", nil)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidParams || rpcErr.Message != "unknown tool: missing" {
+		t.Errorf("unknown tool: err = %v", err)
+	}
+}
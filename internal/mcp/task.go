@@ -0,0 +1,17 @@
+package mcp
+
+import "context"
+
+type taskKey struct{}
+
+// WithTask attaches the user's task to ctx so providers can tailor what
+// they gather to it.
+func WithTask(ctx context.Context, task string) context.Context {
+	return context.WithValue(ctx, taskKey{}, task)
+}
+
+// TaskFrom returns the task attached with WithTask, or "" if there is none.
+func TaskFrom(ctx context.Context) string {
+	task, _ := ctx.Value(taskKey{}).(string)
+	return task
+}
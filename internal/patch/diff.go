@@ -0,0 +1,225 @@
+package patch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Diff computes a line diff between two texts using Myers' algorithm.
+// The result lists every line of both texts tagged as context, deletion
+// or insertion.
+func Diff(oldText, newText string) []Line {
+	a, _ := splitLines(oldText)
+	b, _ := splitLines(newText)
+
+	// Trim the common prefix and suffix so the search only covers the change
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var out []Line
+	for _, l := range a[:prefix] {
+		out = append(out, Line{Op: OpContext, Text: l})
+	}
+	out = append(out, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, l := range a[len(a)-suffix:] {
+		out = append(out, Line{Op: OpContext, Text: l})
+	}
+	return out
+}
+
+// maxEditDistance bounds the Myers search; beyond it the change is shown
+// as a full replacement, which is what it is for practical purposes.
+const maxEditDistance = 2000
+
+// myers returns the shortest edit script turning a into b.
+func myers(a, b []string) []Line {
+	n, m := len(a), len(b)
+	maxD := n + m
+	if maxD == 0 {
+		return nil
+	}
+	if maxD > maxEditDistance {
+		maxD = maxEditDistance
+	}
+
+	offset := maxD
+	v := make([]int, 2*maxD+2)
+	var trace [][]int
+
+	for d := 0; d <= maxD; d++ {
+		snapshot := make([]int, len(v))
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Move down: insertion
+			} else {
+				x = v[offset+k-1] + 1 // Move right: deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, offset, d)
+			}
+		}
+	}
+
+	var out []Line
+	for _, l := range a {
+		out = append(out, Line{Op: OpDelete, Text: l})
+	}
+	for _, l := range b {
+		out = append(out, Line{Op: OpInsert, Text: l})
+	}
+	return out
+}
+
+// backtrack walks the recorded V arrays from the end to rebuild the script.
+func backtrack(a, b []string, trace [][]int, offset, d int) []Line {
+	x, y := len(a), len(b)
+	var reversed []Line
+
+	for ; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, Line{Op: OpContext, Text: a[x]})
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, Line{Op: OpInsert, Text: b[y]})
+		} else {
+			x--
+			reversed = append(reversed, Line{Op: OpDelete, Text: a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		reversed = append(reversed, Line{Op: OpContext, Text: a[x]})
+	}
+
+	out := make([]Line, len(reversed))
+	for i, l := range reversed {
+		out[len(reversed)-1-i] = l
+	}
+	return out
+}
+
+// Group splits a full diff into hunks with the given lines of context
+// around each change. Unchanged regions are left out.
+func Group(lines []Line, context int) []Hunk {
+	// oldBefore[i] is the number of old-text lines preceding lines[i]
+	oldBefore := make([]int, len(lines)+1)
+	for i, l := range lines {
+		oldBefore[i+1] = oldBefore[i]
+		if l.Op != OpInsert {
+			oldBefore[i+1]++
+		}
+	}
+
+	var hunks []Hunk
+	i := 0
+	for {
+		// Skip to the next change
+		for i < len(lines) && lines[i].Op == OpContext {
+			i++
+		}
+		if i == len(lines) {
+			return hunks
+		}
+		start := max(i-context, 0)
+
+		// Extend over changes separated by at most 2*context unchanged lines
+		end := i
+		for end < len(lines) {
+			if lines[end].Op != OpContext {
+				end++
+				continue
+			}
+			run := end
+			for run < len(lines) && lines[run].Op == OpContext {
+				run++
+			}
+			if run == len(lines) || run-end > 2*context {
+				end = min(end+context, len(lines))
+				break
+			}
+			end = run
+		}
+
+		hunks = append(hunks, Hunk{
+			OldStart: oldBefore[start] + 1,
+			Lines:    lines[start:end],
+		})
+		i = end
+	}
+}
+
+// Stats counts inserted and deleted lines in a diff.
+func Stats(lines []Line) (added, removed int) {
+	return Hunk{Lines: lines}.Stats()
+}
+
+// ANSI colors used by Format.
+const (
+	colorReset = "\x1b[0m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+)
+
+// Format renders hunks as a unified diff, optionally with ANSI colors.
+func Format(hunks []Hunk, color bool) string {
+	var sb strings.Builder
+	for _, h := range hunks {
+		if h.OldStart > 0 {
+			writeColored(&sb, fmt.Sprintf("@@ -%d @@", h.OldStart), colorCyan, color)
+		}
+		for _, l := range h.Lines {
+			line := string(l.Op) + l.Text
+			switch l.Op {
+			case OpDelete:
+				writeColored(&sb, line, colorRed, color)
+			case OpInsert:
+				writeColored(&sb, line, colorGreen, color)
+			default:
+				sb.WriteString(line + "\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+func writeColored(sb *strings.Builder, line, code string, color bool) {
+	if color {
+		sb.WriteString(code + line + colorReset + "\n")
+	} else {
+		sb.WriteString(line + "\n")
+	}
+}
@@ -0,0 +1,108 @@
+package patch
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// render writes a diff one line per entry with its op, for comparisons.
+func render(lines []Line) string {
+	var sb strings.Builder
+	for _, l := range lines {
+		sb.WriteString(string(l.Op) + l.Text + "\n")
+	}
+	return sb.String()
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldText, newText string
+		want     string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", " a\n b\n"},
+		{"from empty", "", "a\nb\n", "+a\n+b\n"},
+		{"to empty", "a\n", "", "-a\n"},
+		{"replace middle", "a\nb\nc\n", "a\nx\nc\n", " a\n-b\n+x\n c\n"},
+		{"insert and delete", "a\nb\nc\nd\n", "b\nc\nx\nd\n", "-a\n b\n c\n+x\n d\n"},
+		// The classic example from Myers' paper: ABCABBA -> CBABAC, D = 5
+		{"myers", "A\nB\nC\nA\nB\nB\nA\n", "C\nB\nA\nB\nA\nC\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Diff(tt.oldText, tt.newText)
+
+			// Old and new texts must be recoverable from the script
+			if old := strings.Join(Hunk{Lines: got}.Old(), "\n"); old != strings.TrimSuffix(tt.oldText, "\n") {
+				t.Errorf("old side = %q", old)
+			}
+			if got := strings.Join(Hunk{Lines: got}.New(), "\n"); got != strings.TrimSuffix(tt.newText, "\n") {
+				t.Errorf("new side = %q", got)
+			}
+			if tt.want != "" && render(got) != tt.want {
+				t.Errorf("Diff() =\n%s\nwant\n%s", render(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffIsMinimal(t *testing.T) {
+	got := Diff("A\nB\nC\nA\nB\nB\nA\n", "C\nB\nA\nB\nA\nC\n")
+	if added, removed := Stats(got); added+removed != 5 {
+		t.Errorf("edit distance = %d, want 5:\n%s", added+removed, render(got))
+	}
+}
+
+func TestGroup(t *testing.T) {
+	var old, updated []string
+	for i := 1; i <= 30; i++ {
+		old = append(old, fmt.Sprint(i))
+		switch i {
+		case 5:
+			updated = append(updated, "cinco")
+		case 8:
+			updated = append(updated, "ocho")
+		case 25:
+			// deleted
+		default:
+			updated = append(updated, fmt.Sprint(i))
+		}
+	}
+	diff := Diff(strings.Join(old, "\n")+"\n", strings.Join(updated, "\n")+"\n")
+
+	hunks := Group(diff, 3)
+	if len(hunks) != 2 {
+		t.Fatalf("got %d hunks, want 2:\n%s", len(hunks), Format(hunks, false))
+	}
+
+	// Changes at 5 and 8 are 2 lines apart and share a hunk with 3 lines of context
+	first := hunks[0]
+	if first.OldStart != 2 || render(first.Lines) != " 2\n 3\n 4\n-5\n+cinco\n 6\n 7\n-8\n+ocho\n 9\n 10\n 11\n" {
+		t.Errorf("first hunk at %d:\n%s", first.OldStart, render(first.Lines))
+	}
+	second := hunks[1]
+	if second.OldStart != 22 || render(second.Lines) != " 22\n 23\n 24\n-25\n 26\n 27\n 28\n" {
+		t.Errorf("second hunk at %d:\n%s", second.OldStart, render(second.Lines))
+	}
+
+	// Each hunk applies to the old text on its own
+	for _, h := range hunks {
+		if err := Check(strings.Join(old, "\n")+"\n", h); err != nil {
+			t.Errorf("hunk at %d does not apply: %v", h.OldStart, err)
+		}
+	}
+	if got, err := Apply(strings.Join(old, "\n")+"\n", hunks); err != nil || got != strings.Join(updated, "\n")+"\n" {
+		t.Errorf("Apply(Group) = %q, %v", got, err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	h := hunk(3, " a", "-b", "+c")
+	if got := Format([]Hunk{h}, false); got != "@@ -3 @@\n a\n-b\n+c\n" {
+		t.Errorf("Format() = %q", got)
+	}
+	if got := Format([]Hunk{h}, true); !strings.Contains(got, colorRed+"-b"+colorReset) {
+		t.Errorf("Format(color) = %q", got)
+	}
+}
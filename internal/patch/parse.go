@@ -0,0 +1,223 @@
+package patch
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	fenceRe      = regexp.MustCompile("(?s)```([\\w+-]*)[^\\n]*\\n(.*?)```")
+	hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+)
+
+const (
+	searchMarker  = "<<<<<<< SEARCH"
+	dividerMarker = "======="
+	replaceMarker = ">>>>>>> REPLACE"
+)
+
+// Parse extracts file edits from a model response: fenced unified diffs
+// (```diff or ```patch, or any fence whose body starts with diff headers)
+// and SEARCH/REPLACE blocks. Edits for the same file are merged in order.
+func Parse(response string) []Edit {
+	var edits []Edit
+
+	for _, m := range fenceRe.FindAllStringSubmatch(response, -1) {
+		lang, body := m[1], m[2]
+		if lang == "diff" || lang == "patch" || looksLikeDiff(body) {
+			edits = append(edits, ParseUnified(body)...)
+		}
+	}
+	edits = append(edits, ParseSearchReplace(response)...)
+
+	return merge(edits)
+}
+
+// IsEdit reports whether a code block body is a diff or SEARCH/REPLACE
+// block rather than full file contents.
+func IsEdit(body string) bool {
+	return looksLikeDiff(body) || strings.Contains(body, searchMarker)
+}
+
+func looksLikeDiff(body string) bool {
+	trimmed := strings.TrimLeft(body, "\n")
+	return strings.HasPrefix(trimmed, "diff --git ") ||
+		strings.HasPrefix(trimmed, "--- ") && strings.Contains(trimmed, "\n+++ ") ||
+		strings.HasPrefix(trimmed, "@@ -")
+}
+
+// ParseUnified parses a unified diff. Hunk line counts in headers are not
+// trusted, since models frequently get them wrong; a hunk ends at the next
+// hunk or file header.
+func ParseUnified(diff string) []Edit {
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+
+	var edits []Edit
+	var current *Edit
+	var hunk *Hunk
+
+	flushHunk := func() {
+		if current != nil && hunk != nil && len(hunk.Lines) > 0 {
+			current.Hunks = append(current.Hunks, *hunk)
+		}
+		hunk = nil
+	}
+	flushEdit := func() {
+		flushHunk()
+		if current != nil && current.Path != "" && len(current.Hunks) > 0 {
+			edits = append(edits, *current)
+		}
+		current = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flushEdit()
+
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			flushEdit()
+			oldPath := diffPath(line[4:])
+			newPath := diffPath(lines[i+1][4:])
+			i++
+
+			current = &Edit{Path: newPath}
+			if oldPath == "" {
+				current.NewFile = true
+			}
+			if newPath == "" {
+				// Deletions are not supported; drop the hunks that follow
+				current = &Edit{}
+			}
+
+		case strings.HasPrefix(line, "@@"):
+			flushHunk()
+			if current == nil {
+				// Bare hunks without file headers cannot be placed
+				current = &Edit{}
+			}
+			hunk = &Hunk{}
+			if m := hunkHeaderRe.FindStringSubmatch(line); m != nil {
+				hunk.OldStart, _ = strconv.Atoi(m[1])
+			}
+
+		case hunk != nil:
+			switch {
+			case line == "":
+				// Blank context lines often lose their leading space
+				hunk.Lines = append(hunk.Lines, Line{Op: OpContext})
+			case line[0] == ' ' || line[0] == '-' || line[0] == '+':
+				hunk.Lines = append(hunk.Lines, Line{Op: Op(line[0]), Text: line[1:]})
+			case line[0] == '\\':
+				// "\ No newline at end of file"
+			default:
+				flushHunk()
+			}
+		}
+	}
+	flushEdit()
+
+	return edits
+}
+
+// diffPath extracts the file path from a ---/+++ header, stripping the
+// a/ b/ prefixes and timestamps. /dev/null yields "".
+func diffPath(header string) string {
+	path := strings.TrimSpace(header)
+	if i := strings.IndexByte(path, '\t'); i >= 0 {
+		path = path[:i]
+	}
+	if path == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		path = path[2:]
+	}
+	return path
+}
+
+// ParseSearchReplace extracts SEARCH/REPLACE blocks of the form:
+//
+//	path/to/file.go
+//	<<<<<<< SEARCH
+//	old lines
+//	=======
+//	new lines
+//	>>>>>>> REPLACE
+//
+// The file name is the closest non-empty line above the block that is not
+// a code fence; surrounding backticks or asterisks are removed.
+func ParseSearchReplace(response string) []Edit {
+	lines := strings.Split(response, "\n")
+
+	var edits []Edit
+	for i := 0; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != searchMarker {
+			continue
+		}
+
+		path := findPathAbove(lines, i)
+
+		var old, replacement []string
+		j := i + 1
+		for ; j < len(lines) && strings.TrimSpace(lines[j]) != dividerMarker; j++ {
+			old = append(old, lines[j])
+		}
+		k := j + 1
+		for ; k < len(lines) && strings.TrimSpace(lines[k]) != replaceMarker; k++ {
+			replacement = append(replacement, lines[k])
+		}
+		if j >= len(lines) || k >= len(lines) {
+			break // Unterminated block
+		}
+		i = k
+
+		if path == "" {
+			continue
+		}
+
+		var h Hunk
+		for _, l := range old {
+			h.Lines = append(h.Lines, Line{Op: OpDelete, Text: l})
+		}
+		for _, l := range replacement {
+			h.Lines = append(h.Lines, Line{Op: OpInsert, Text: l})
+		}
+		edits = append(edits, Edit{Path: path, Hunks: []Hunk{h}, NewFile: len(old) == 0})
+	}
+
+	return edits
+}
+
+func findPathAbove(lines []string, i int) string {
+	for j := i - 1; j >= 0 && j >= i-3; j-- {
+		line := strings.TrimSpace(lines[j])
+		if line == "" || strings.HasPrefix(line, "```") {
+			continue
+		}
+		line = strings.Trim(line, "`*: ")
+		if strings.ContainsAny(line, " \t") {
+			return ""
+		}
+		return line
+	}
+	return ""
+}
+
+// merge combines edits for the same path, keeping first-seen order.
+func merge(edits []Edit) []Edit {
+	var out []Edit
+	index := make(map[string]int)
+	for _, e := range edits {
+		if i, ok := index[e.Path]; ok {
+			out[i].Hunks = append(out[i].Hunks, e.Hunks...)
+			continue
+		}
+		index[e.Path] = len(out)
+		out = append(out, e)
+	}
+	return out
+}
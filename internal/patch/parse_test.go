@@ -0,0 +1,113 @@
+package patch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseUnified(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -3,4 +3,4 @@ func main() {
+ func main() {
+-	fmt.Println("hola")
++	fmt.Println("adiós")
+
+@@ -20 +20,2 @@
+ }
++// fin
+\ No newline at end of file
+--- /dev/null
++++ b/docs/nuevo.md
+@@ -0,0 +1,2 @@
++# Nuevo
++texto
+--- a/borrado.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package borrado
+`
+	want := []Edit{
+		{Path: "main.go", Hunks: []Hunk{
+			hunk(3, " func main() {", "-\tfmt.Println(\"hola\")", "+\tfmt.Println(\"adiós\")", " "),
+			hunk(20, " }", "+// fin"),
+		}},
+		{Path: "docs/nuevo.md", NewFile: true, Hunks: []Hunk{hunk(0, "+# Nuevo", "+texto")}},
+	}
+	if got := ParseUnified(diff); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseUnified() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseUnifiedIgnoresBareHunks(t *testing.T) {
+	if got := ParseUnified("@@ -1 +1 @@\n-a\n+b\n"); len(got) != 0 {
+		t.Errorf("ParseUnified() = %+v, want no edits", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	response := "Cambios:\n\n```diff\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-x\n+y\n```\n\n" +
+		"**b.go**\n```go\n<<<<<<< SEARCH\nold\n=======\nnew\n>>>>>>> REPLACE\n```\n\n" +
+		"`a.go`\n<<<<<<< SEARCH\nz\n=======\nw\n>>>>>>> REPLACE\n\n" +
+		"```go\npackage main // no es un cambio\n```\n"
+
+	want := []Edit{
+		{Path: "a.go", Hunks: []Hunk{hunk(1, "-x", "+y"), hunk(0, "-z", "+w")}},
+		{Path: "b.go", Hunks: []Hunk{hunk(0, "-old", "+new")}},
+	}
+	if got := Parse(response); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseSearchReplace(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     []Edit
+	}{
+		{
+			"creates a file with an empty search",
+			"nuevo.txt\n<<<<<<< SEARCH\n=======\nhola\n>>>>>>> REPLACE\n",
+			[]Edit{{Path: "nuevo.txt", NewFile: true, Hunks: []Hunk{hunk(0, "+hola")}}},
+		},
+		{
+			"no path above",
+			"Cambia esto por favor\n<<<<<<< SEARCH\na\n=======\nb\n>>>>>>> REPLACE\n",
+			nil,
+		},
+		{
+			"unterminated block",
+			"a.go\n<<<<<<< SEARCH\na\n=======\nb\n",
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseSearchReplace(tt.response); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSearchReplace() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsEdit(t *testing.T) {
+	tests := []struct {
+		body string
+		want bool
+	}{
+		{"diff --git a/x b/x\n", true},
+		{"--- a/x\n+++ b/x\n", true},
+		{"\n@@ -1 +1 @@\n", true},
+		{"x\n<<<<<<< SEARCH\n", true},
+		{"--- título\ntexto\n", false},
+		{"package main\n", false},
+	}
+	for _, tt := range tests {
+		if got := IsEdit(tt.body); got != tt.want {
+			t.Errorf("IsEdit(%q) = %v, want %v", tt.body, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package patch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op identifies the kind of a line inside a hunk.
+type Op byte
+
+const (
+	OpContext Op = ' '
+	OpDelete  Op = '-'
+	OpInsert  Op = '+'
+)
+
+// Line is a single line of a hunk.
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Hunk is a contiguous change: the Old lines (context and deletions) are
+// replaced by the New lines (context and insertions).
+type Hunk struct {
+	// OldStart is the 1-based line where the hunk is expected to apply,
+	// taken from a unified diff header. Zero means unknown, as in
+	// search/replace blocks, and the hunk must match exactly once.
+	OldStart int
+	Lines    []Line
+}
+
+// Old returns the lines the hunk expects to find in the file.
+func (h Hunk) Old() []string {
+	var out []string
+	for _, l := range h.Lines {
+		if l.Op != OpInsert {
+			out = append(out, l.Text)
+		}
+	}
+	return out
+}
+
+// New returns the lines that replace Old.
+func (h Hunk) New() []string {
+	var out []string
+	for _, l := range h.Lines {
+		if l.Op != OpDelete {
+			out = append(out, l.Text)
+		}
+	}
+	return out
+}
+
+// Stats returns the number of inserted and deleted lines.
+func (h Hunk) Stats() (added, removed int) {
+	for _, l := range h.Lines {
+		switch l.Op {
+		case OpInsert:
+			added++
+		case OpDelete:
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// String renders the hunk in unified diff notation.
+func (h Hunk) String() string {
+	return Format([]Hunk{h}, false)
+}
+
+// Edit is a set of hunks for a single file.
+type Edit struct {
+	Path  string
+	Hunks []Hunk
+
+	// NewFile is set when a unified diff creates the file (--- /dev/null).
+	NewFile bool
+}
+
+// Apply applies hunks in order to content and returns the result.
+// Every hunk must match the current text; otherwise an error naming the
+// failing hunk is returned and content is left untouched.
+func Apply(content string, hunks []Hunk) (string, error) {
+	lines, trailingNewline := splitLines(content)
+
+	offset := 0 // Shift of later hunks caused by earlier ones
+	for i, h := range hunks {
+		old := h.Old()
+
+		pos, err := locate(lines, old, h.OldStart, offset)
+		if err != nil {
+			return "", fmt.Errorf("hunk %d: %w", i+1, err)
+		}
+
+		replacement := h.New()
+		updated := make([]string, 0, len(lines)-len(old)+len(replacement))
+		updated = append(updated, lines[:pos]...)
+		updated = append(updated, replacement...)
+		updated = append(updated, lines[pos+len(old):]...)
+		lines = updated
+
+		offset += len(replacement) - len(old)
+	}
+
+	if len(lines) == 0 {
+		return "", nil
+	}
+	out := strings.Join(lines, "\n")
+	if trailingNewline || content == "" {
+		out += "\n"
+	}
+	return out, nil
+}
+
+// Check reports whether a single hunk applies to content.
+func Check(content string, h Hunk) error {
+	_, err := Apply(content, []Hunk{h})
+	return err
+}
+
+// locate finds where old appears in lines. With a position hint the closest
+// match wins; without one the match must be unique.
+func locate(lines, old []string, oldStart, offset int) (int, error) {
+	if len(old) == 0 {
+		// Pure insertion: only possible with a position hint or an empty file
+		switch {
+		case oldStart > 0:
+			pos := min(max(oldStart+offset, 0), len(lines))
+			return pos, nil
+		case len(lines) == 0:
+			return 0, nil
+		}
+		return 0, fmt.Errorf("insertion without context or line number")
+	}
+
+	matches := findAll(lines, old, equalExact)
+	if len(matches) == 0 {
+		// Models often mangle trailing whitespace; retry ignoring it
+		matches = findAll(lines, old, equalTrimmed)
+	}
+
+	switch {
+	case len(matches) == 0:
+		return 0, fmt.Errorf("context not found in file (first line: %q)", old[0])
+	case len(matches) == 1:
+		return matches[0], nil
+	case oldStart > 0:
+		want := oldStart - 1 + offset
+		best := matches[0]
+		for _, m := range matches[1:] {
+			if abs(m-want) < abs(best-want) {
+				best = m
+			}
+		}
+		return best, nil
+	}
+	return 0, fmt.Errorf("ambiguous: text appears %d times in the file", len(matches))
+}
+
+func findAll(lines, old []string, eq func(a, b string) bool) []int {
+	var matches []int
+	for i := 0; i+len(old) <= len(lines); i++ {
+		ok := true
+		for j := range old {
+			if !eq(lines[i+j], old[j]) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+func equalExact(a, b string) bool { return a == b }
+
+func equalTrimmed(a, b string) bool {
+	return strings.TrimRight(a, " \t\r") == strings.TrimRight(b, " \t\r")
+}
+
+// splitLines splits content into lines without their terminators and
+// reports whether the text ended with a newline.
+func splitLines(content string) ([]string, bool) {
+	if content == "" {
+		return nil, false
+	}
+	trailing := strings.HasSuffix(content, "\n")
+	content = strings.TrimSuffix(content, "\n")
+	return strings.Split(content, "\n"), trailing
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
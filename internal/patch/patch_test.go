@@ -0,0 +1,109 @@
+package patch
+
+import (
+	"strings"
+	"testing"
+)
+
+func hunk(oldStart int, lines ...string) Hunk {
+	h := Hunk{OldStart: oldStart}
+	for _, l := range lines {
+		h.Lines = append(h.Lines, Line{Op: Op(l[0]), Text: l[1:]})
+	}
+	return h
+}
+
+func TestApply(t *testing.T) {
+	file := "package a\n\nfunc A() {\n\treturn\n}\n\nfunc B() {\n\treturn\n}\n"
+	tests := []struct {
+		name    string
+		content string
+		hunks   []Hunk
+		want    string
+	}{
+		{
+			"exact position",
+			file,
+			[]Hunk{hunk(3, " func A() {", "-\treturn", "+\tpanic(1)", " }")},
+			"package a\n\nfunc A() {\n\tpanic(1)\n}\n\nfunc B() {\n\treturn\n}\n",
+		},
+		{
+			"wrong line number, unique context",
+			file,
+			[]Hunk{hunk(40, " func B() {", "-\treturn", "+\tpanic(2)")},
+			"package a\n\nfunc A() {\n\treturn\n}\n\nfunc B() {\n\tpanic(2)\n}\n",
+		},
+		{
+			"ambiguous context resolved by the closest line",
+			file,
+			[]Hunk{hunk(8, "-\treturn", "+\tpanic(2)")},
+			"package a\n\nfunc A() {\n\treturn\n}\n\nfunc B() {\n\tpanic(2)\n}\n",
+		},
+		{
+			"second hunk shifted by the first",
+			file,
+			[]Hunk{
+				hunk(1, " package a", "+", "+import \"fmt\""),
+				hunk(8, "-\treturn", "+\tfmt.Println()"),
+			},
+			"package a\n\nimport \"fmt\"\n\nfunc A() {\n\treturn\n}\n\nfunc B() {\n\tfmt.Println()\n}\n",
+		},
+		{
+			"trailing whitespace ignored",
+			"a  \nb\n",
+			[]Hunk{hunk(0, "-a", "+A")},
+			"A\nb\n",
+		},
+		{
+			"no trailing newline kept",
+			"a\nb",
+			[]Hunk{hunk(0, "-b", "+c")},
+			"a\nc",
+		},
+		{
+			"new file",
+			"",
+			[]Hunk{hunk(0, "+line 1", "+line 2")},
+			"line 1\nline 2\n",
+		},
+		{
+			"insertion by line number",
+			"a\nb\n",
+			[]Hunk{hunk(2, "+x")},
+			"a\nb\nx\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(tt.content, tt.hunks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Apply() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	file := "x := 1\ny := 2\nx := 1\n"
+	tests := []struct {
+		name  string
+		hunks []Hunk
+		want  string
+	}{
+		{"context not found", []Hunk{hunk(1, " z := 3", "-y := 2")}, `hunk 1: context not found in file (first line: "z := 3")`},
+		{"ambiguous without line number", []Hunk{hunk(0, "-x := 1", "+x := 3")}, "hunk 1: ambiguous: text appears 2 times"},
+		{"insertion without position", []Hunk{hunk(0, "+z := 3")}, "hunk 1: insertion without context"},
+		{"second hunk fails", []Hunk{hunk(2, "-y := 2"), hunk(0, "-y := 2")}, "hunk 2: context not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Apply(file, tt.hunks)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -3,35 +3,117 @@ package prompt
 import (
 	"fmt"
 	"strings"
+	"text/template"
 
 	"ollama-cli/internal/mcp"
 )
 
+// DefaultUserTemplate is the layout of the user message when a prompt does
+// not define its own: one "## Context" section per provider, then the task.
+const DefaultUserTemplate = `{{range .Contexts}}{{if .Error}}## Context: {{.Provider}}
+[Error: {{.Error}}]
+
+{{else if .Content}}## Context: {{.Provider}}
+{{.Content}}
+
+{{end}}{{end}}## Task
+{{.Task}}`
+
+// Vars are the values available to templates besides the task and the
+// contexts.
+type Vars struct {
+	Branch   string // current git branch, if any
+	Language string // language the answers should be in
+	Model    string
+	Prompt   string // name of the prompt in use
+}
+
+// Data is what templates are executed with: {{.Task}}, {{.Branch}},
+// {{range .Contexts}}{{.Provider}} {{.Content}}{{end}}...
+type Data struct {
+	Vars
+	Task     string
+	Contexts []mcp.ContextResult
+}
+
+// Builder renders the system and user messages. The system prompt is a
+// text/template; it may {{define "user"}} to change how the context is laid
+// out, and may include shared snippets with {{template "name" .}}.
 type Builder struct {
-	systemPrompt string
+	tmpl *template.Template
+	vars Vars
 }
 
+// NewBuilder creates a builder with the default user layout. A system
+// prompt that is not a valid template is used verbatim.
 func NewBuilder(systemPrompt string) *Builder {
-	return &Builder{systemPrompt: systemPrompt}
+	if b, err := NewTemplateBuilder(systemPrompt, nil); err == nil {
+		return b
+	}
+	literal := template.FuncMap{"literal": func() string { return systemPrompt }}
+	root := template.Must(template.New("system").Funcs(literal).Parse(`{{literal}}`))
+	template.Must(root.New("user").Parse(DefaultUserTemplate))
+	return &Builder{tmpl: root}
 }
 
-func (b *Builder) Build(contexts []mcp.ContextResult, task string) (system, user string) {
-	system = b.systemPrompt
-
-	var parts []string
+// NewTemplateBuilder parses systemPrompt and the snippets (name → template
+// text). The templates are tried once with sample data so that mistakes
+// such as unknown fields or snippets are reported here rather than on
+// every question.
+func NewTemplateBuilder(systemPrompt string, snippets map[string]string) (*Builder, error) {
+	root := template.New("system").Option("missingkey=error")
+	for name, text := range snippets {
+		if _, err := root.New(name).Parse(text); err != nil {
+			return nil, fmt.Errorf("snippet %s: %w", name, err)
+		}
+	}
+	if _, err := root.Parse(systemPrompt); err != nil {
+		return nil, err
+	}
+	if root.Lookup("user") == nil {
+		if _, err := root.New("user").Parse(DefaultUserTemplate); err != nil {
+			return nil, err
+		}
+	}
 
-	// Add context sections
-	for _, ctx := range contexts {
-		if ctx.Error != "" {
-			parts = append(parts, fmt.Sprintf("## Context: %s\n[Error: %s]", ctx.Provider, ctx.Error))
-		} else if ctx.Content != "" {
-			parts = append(parts, fmt.Sprintf("## Context: %s\n%s", ctx.Provider, ctx.Content))
+	b := &Builder{tmpl: root}
+	sample := Data{
+		Vars:     Vars{Branch: "main", Language: "español", Model: "model", Prompt: "default"},
+		Task:     "task",
+		Contexts: []mcp.ContextResult{{Provider: "filesystem", Content: "content"}},
+	}
+	for _, name := range []string{"system", "user"} {
+		if _, err := b.execute(name, sample); err != nil {
+			return nil, err
 		}
 	}
+	return b, nil
+}
+
+// SetVars sets the variables used by the next builds.
+func (b *Builder) SetVars(vars Vars) {
+	b.vars = vars
+}
 
-	// Add user task
-	parts = append(parts, fmt.Sprintf("## Task\n%s", task))
+func (b *Builder) Build(contexts []mcp.ContextResult, task string) (system, user string) {
+	data := Data{Vars: b.vars, Task: task, Contexts: contexts}
 
-	user = strings.Join(parts, "\n\n")
+	system, err := b.execute("system", data)
+	if err != nil {
+		system = fmt.Sprintf("[Error en la plantilla del sistema: %v]", err)
+	}
+	user, err = b.execute("user", data)
+	if err != nil {
+		// Never lose the question because of a broken layout
+		user = fmt.Sprintf("[Error en la plantilla: %v]\n\n## Task\n%s", err, task)
+	}
 	return system, user
 }
+
+func (b *Builder) execute(name string, data Data) (string, error) {
+	var sb strings.Builder
+	if err := b.tmpl.ExecuteTemplate(&sb, name, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
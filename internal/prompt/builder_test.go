@@ -0,0 +1,119 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"ollama-cli/internal/mcp"
+)
+
+// legacyUser is the user message as Build laid it out before templates.
+func legacyUser(contexts []mcp.ContextResult, task string) string {
+	var parts []string
+	for _, ctx := range contexts {
+		if ctx.Error != "" {
+			parts = append(parts, fmt.Sprintf("## Context: %s\n[Error: %s]", ctx.Provider, ctx.Error))
+		} else if ctx.Content != "" {
+			parts = append(parts, fmt.Sprintf("## Context: %s\n%s", ctx.Provider, ctx.Content))
+		}
+	}
+	parts = append(parts, fmt.Sprintf("## Task\n%s", task))
+	return strings.Join(parts, "\n\n")
+}
+
+func TestBuildDefaultLayout(t *testing.T) {
+	tests := [][]mcp.ContextResult{
+		nil,
+		{{Provider: "filesystem", Content: "main.go\ngo.mod"}},
+		{
+			{Provider: "git", Error: "not a git repository"},
+			{Provider: "empty"},
+			{Provider: "filesystem", Content: "a\n\nb\n"},
+			{Provider: "go-outline", Content: "package main"},
+		},
+	}
+	for i, contexts := range tests {
+		system, user := NewBuilder("Eres un asistente.").Build(contexts, "explica main")
+		if system != "Eres un asistente." {
+			t.Errorf("%d: system = %q", i, system)
+		}
+		if want := legacyUser(contexts, "explica main"); user != want {
+			t.Errorf("%d: user =\n%q\nwant\n%q", i, user, want)
+		}
+	}
+}
+
+func TestBuildVarsAndUserOverride(t *testing.T) {
+	b, err := NewTemplateBuilder(`Rama {{.Branch}}, responde en {{.Language}}.
+{{define "user"}}{{.Task}}
+{{range .Contexts}}<{{.Provider}}>{{.Content}}</{{.Provider}}>
+{{end}}{{end}}`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.SetVars(Vars{Branch: "dev", Language: "inglés"})
+
+	system, user := b.Build([]mcp.ContextResult{{Provider: "git", Content: "diff"}}, "revisa")
+	if system != "Rama dev, responde en inglés." {
+		t.Errorf("system = %q", system)
+	}
+	if want := "revisa\n<git>diff</git>"; user != want {
+		t.Errorf("user = %q, want %q", user, want)
+	}
+}
+
+func TestBuildSnippets(t *testing.T) {
+	snippets := map[string]string{
+		"tono":   "Sé breve.",
+		"estilo": `{{template "tono" .}} Usa {{.Model}}.`,
+	}
+	b, err := NewTemplateBuilder(`Eres revisor. {{template "estilo" .}}`, snippets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.SetVars(Vars{Model: "llama3"})
+
+	if system, _ := b.Build(nil, "x"); system != "Eres revisor. Sé breve. Usa llama3." {
+		t.Errorf("system = %q", system)
+	}
+}
+
+func TestNewTemplateBuilderErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		system   string
+		snippets map[string]string
+		want     string
+	}{
+		{"unknown field", "Hola {{.Nombre}}", nil, "Nombre"},
+		{"unknown field in user", `{{define "user"}}{{.Tarea}}{{end}}`, nil, "Tarea"},
+		{"unknown snippet", `{{template "falta" .}}`, nil, "falta"},
+		{"bad snippet", "Hola", map[string]string{"roto": "{{if}}"}, "snippet roto"},
+		{"syntax", "Hola {{", nil, "unclosed action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTemplateBuilder(tt.system, tt.snippets)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewBuilderLiteralFallback(t *testing.T) {
+	// Not valid templates: NewBuilder uses them verbatim
+	for _, prompt := range []string{
+		"Usa la sintaxis {{ de Jinja",
+		"Escribe {{.Desconocido}} sin cambios",
+	} {
+		system, user := NewBuilder(prompt).Build(nil, "tarea")
+		if system != prompt {
+			t.Errorf("system = %q, want %q", system, prompt)
+		}
+		if user != "## Task\ntarea" {
+			t.Errorf("user = %q", user)
+		}
+	}
+}
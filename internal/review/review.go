@@ -0,0 +1,287 @@
+// Package review parses and formats code review findings produced by the
+// model.
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Severity ranks findings; higher is worse.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+var severityNames = []string{"info", "low", "medium", "high", "critical"}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return "info"
+	}
+	return severityNames[s]
+}
+
+// MarshalJSON writes the severity by name.
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON accepts any name understood by ParseSeverity. Unknown
+// names become SeverityCritical rather than failing the whole review: a
+// finding the model rated in its own words must not slip past --fail-on.
+// An empty severity is treated like a missing one (SeverityInfo).
+func (s *Severity) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	if strings.TrimSpace(name) == "" {
+		*s = SeverityInfo
+		return nil
+	}
+	parsed, err := ParseSeverity(name)
+	if err != nil {
+		parsed = SeverityCritical
+	}
+	*s = parsed
+	return nil
+}
+
+// severityAliases maps the words models use to the canonical levels.
+var severityAliases = map[string]Severity{
+	"info": SeverityInfo, "information": SeverityInfo, "informational": SeverityInfo,
+	"nit": SeverityInfo, "nitpick": SeverityInfo, "note": SeverityInfo, "hint": SeverityInfo,
+	"suggestion": SeverityInfo, "style": SeverityInfo, "trivial": SeverityInfo, "optional": SeverityInfo,
+	"sugerencia": SeverityInfo, "nota": SeverityInfo,
+
+	"low": SeverityLow, "minor": SeverityLow, "baja": SeverityLow, "leve": SeverityLow, "menor": SeverityLow,
+
+	"medium": SeverityMedium, "moderate": SeverityMedium, "warning": SeverityMedium, "warn": SeverityMedium,
+	"normal": SeverityMedium, "media": SeverityMedium, "moderada": SeverityMedium, "advertencia": SeverityMedium,
+
+	"high": SeverityHigh, "major": SeverityHigh, "error": SeverityHigh, "bug": SeverityHigh,
+	"important": SeverityHigh, "serious": SeverityHigh, "severe": SeverityHigh,
+	"alta": SeverityHigh, "grave": SeverityHigh, "importante": SeverityHigh, "mayor": SeverityHigh,
+
+	"critical": SeverityCritical, "blocker": SeverityCritical, "blocking": SeverityCritical,
+	"fatal": SeverityCritical, "security": SeverityCritical, "urgent": SeverityCritical,
+	"crítica": SeverityCritical, "critica": SeverityCritical, "crítico": SeverityCritical,
+	"critico": SeverityCritical, "bloqueante": SeverityCritical,
+}
+
+// ParseSeverity converts a name to a Severity.
+func ParseSeverity(name string) (Severity, error) {
+	if s, ok := severityAliases[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return s, nil
+	}
+	return SeverityInfo, fmt.Errorf("unknown severity %q (use %s)", name, strings.Join(severityNames, ", "))
+}
+
+// Finding is a single review comment.
+type Finding struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// ErrNoFindings is returned by Parse when the response holds no JSON.
+var ErrNoFindings = errors.New("the response does not contain a findings JSON object")
+
+// Parse extracts findings from a model response. The model is asked for
+// {"findings": [...]}, but a bare array and surrounding prose or code
+// fences are tolerated.
+func Parse(response string) ([]Finding, error) {
+	empty := false
+	for _, candidate := range jsonCandidates(response) {
+		var wrapped struct {
+			Findings []Finding `json:"findings"`
+		}
+		if err := json.Unmarshal([]byte(candidate), &wrapped); err == nil && wrapped.Findings != nil {
+			return clean(wrapped.Findings), nil
+		}
+		var list []Finding
+		if err := json.Unmarshal([]byte(candidate), &list); err == nil {
+			// "[]" may just be prose; keep looking for a real answer
+			if len(list) > 0 {
+				return clean(list), nil
+			}
+			empty = true
+		}
+	}
+	if empty {
+		return nil, nil
+	}
+	return nil, ErrNoFindings
+}
+
+var fencePattern = regexp.MustCompile("(?s)```([A-Za-z]*)[ \t]*\n(.*?)\n[ \t]*```")
+
+// jsonCandidates returns substrings that may be the JSON answer, best
+// first: the contents of ```json fences, of other fences, then every
+// balanced {...} and [...] outside strings, and finally the span from the
+// first '{' to the last '}'.
+func jsonCandidates(s string) []string {
+	var out []string
+	var other []string
+	for _, m := range fencePattern.FindAllStringSubmatch(s, -1) {
+		if strings.EqualFold(m[1], "json") {
+			out = append(out, m[2])
+		} else {
+			other = append(other, m[2])
+		}
+	}
+	out = append(out, other...)
+	out = append(out, balanced(s, '{', '}')...)
+	out = append(out, balanced(s, '[', ']')...)
+
+	if start, end := strings.Index(s, "{"), strings.LastIndex(s, "}"); start >= 0 && end > start {
+		out = append(out, s[start:end+1])
+	}
+	return out
+}
+
+// maxBalanced caps how many opening brackets balanced tries, so a long
+// response full of braces stays cheap.
+const maxBalanced = 64
+
+// balanced returns the outermost substrings of s that start with open and
+// end with its matching close, ignoring brackets inside JSON strings.
+func balanced(s string, open, close byte) []string {
+	var out []string
+	tries := 0
+	for i := 0; i < len(s) && tries < maxBalanced; i++ {
+		if s[i] != open {
+			continue
+		}
+		tries++
+		if end := matching(s, i, open, close); end > 0 {
+			out = append(out, s[i:end+1])
+			i = end
+		}
+	}
+	return out
+}
+
+// matching returns the index of the bracket closing the one at start, or
+// -1 if it is never closed.
+func matching(s string, start int, open, close byte) int {
+	depth := 0
+	inString, escaped := false, false
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == open:
+			depth++
+		case c == close:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func clean(findings []Finding) []Finding {
+	out := findings[:0]
+	for _, f := range findings {
+		f.File = strings.TrimPrefix(strings.TrimSpace(f.File), "./")
+		f.Message = strings.TrimSpace(f.Message)
+		if f.Message != "" {
+			out = append(out, f)
+		}
+	}
+	Sort(out)
+	return out
+}
+
+// Sort orders findings by file, then line.
+func Sort(findings []Finding) {
+	sort.SliceStable(findings, func(a, b int) bool {
+		if findings[a].File != findings[b].File {
+			return findings[a].File < findings[b].File
+		}
+		return findings[a].Line < findings[b].Line
+	})
+}
+
+// AtLeast returns the findings with severity min or worse.
+func AtLeast(findings []Finding, min Severity) []Finding {
+	var out []Finding
+	for _, f := range findings {
+		if f.Severity >= min {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// FormatText renders findings grouped by file. Findings must be sorted.
+func FormatText(findings []Finding) string {
+	if len(findings) == 0 {
+		return "Sin observaciones.\n"
+	}
+
+	var sb strings.Builder
+	file := "\x00"
+	for _, f := range findings {
+		if f.File != file {
+			if file != "\x00" {
+				sb.WriteString("\n")
+			}
+			file = f.File
+			name := file
+			if name == "" {
+				name = "(general)"
+			}
+			sb.WriteString(name + "\n")
+		}
+		location := "   -"
+		if f.Line > 0 {
+			location = fmt.Sprintf("%4d", f.Line)
+		}
+		fmt.Fprintf(&sb, "  %s  [%s] %s\n", location, f.Severity, indent(f.Message))
+	}
+	return sb.String()
+}
+
+// indent aligns continuation lines of multi-line messages.
+func indent(message string) string {
+	return strings.ReplaceAll(message, "\n", "\n        ")
+}
+
+// FormatJSON renders findings as {"findings": [...]}.
+func FormatJSON(findings []Finding) (string, error) {
+	if findings == nil {
+		findings = []Finding{}
+	}
+	data, err := json.MarshalIndent(struct {
+		Findings []Finding `json:"findings"`
+	}{findings}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
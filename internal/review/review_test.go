@@ -0,0 +1,85 @@
+package review
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     []Finding
+	}{
+		{
+			"plain object",
+			`{"findings":[{"file":"./b.go","line":3,"severity":"high","message":" nil map "},{"file":"a.go","severity":"nit","message":"typo"}]}`,
+			[]Finding{{File: "a.go", Severity: SeverityInfo, Message: "typo"}, {File: "b.go", Line: 3, Severity: SeverityHigh, Message: "nil map"}},
+		},
+		{
+			"json fence after prose with braces",
+			"Revisé el uso de {key: value} en config.\n\n```json\n{\"findings\":[{\"file\":\"a.go\",\"severity\":\"medium\",\"message\":\"x\"}]}\n```\n\nNo hay más {observaciones}.",
+			[]Finding{{File: "a.go", Severity: SeverityMedium, Message: "x"}},
+		},
+		{
+			"balanced object among prose braces",
+			`Mira {esto}: {"findings":[{"file":"a.go","severity":"low","message":"usa {} vacío"}]} y {aquello}`,
+			[]Finding{{File: "a.go", Severity: SeverityLow, Message: "usa {} vacío"}},
+		},
+		{
+			"bare list",
+			"```\n[{\"file\":\"a.go\",\"severity\":\"critical\",\"message\":\"x\"}]\n```",
+			[]Finding{{File: "a.go", Severity: SeverityCritical, Message: "x"}},
+		},
+		{
+			"empty list",
+			`{"findings":[]}`,
+			[]Finding{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.response)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNoJSON(t *testing.T) {
+	if _, err := Parse("Todo bien, sin {observaciones}."); !errors.Is(err, ErrNoFindings) {
+		t.Errorf("err = %v, want ErrNoFindings", err)
+	}
+}
+
+func TestSeverityUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		want Severity
+	}{
+		{"", SeverityInfo},
+		{"Nitpick", SeverityInfo},
+		{"minor", SeverityLow},
+		{"warning", SeverityMedium},
+		{"bug", SeverityHigh},
+		{" Blocker ", SeverityCritical},
+		{"showstopper", SeverityCritical},
+	}
+	for _, tt := range tests {
+		var f Finding
+		if err := f.Severity.UnmarshalJSON([]byte(`"` + tt.name + `"`)); err != nil {
+			t.Fatal(err)
+		}
+		if f.Severity != tt.want {
+			t.Errorf("severity %q = %v, want %v", tt.name, f.Severity, tt.want)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ollama-cli/internal/llm"
+)
+
+// Session is an interactive conversation persisted to disk so it can be
+// resumed after the terminal is closed.
+type Session struct {
+	ID             string        `json:"id"`
+	Model          string        `json:"model"`
+	WorkDir        string        `json:"work_dir"`
+	ContextSummary string        `json:"context_summary,omitempty"`
+	Messages       []llm.Message `json:"messages"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// Title returns the first user question, used to identify the session in listings.
+func (s *Session) Title() string {
+	for _, m := range s.Messages {
+		if m.Role == llm.RoleUser {
+			title := strings.Join(strings.Fields(m.Content), " ")
+			if runes := []rune(title); len(runes) > 60 {
+				title = string(runes[:57]) + "..."
+			}
+			return title
+		}
+	}
+	return ""
+}
+
+// Turns returns the number of questions asked in the session.
+func (s *Session) Turns() int {
+	n := 0
+	for _, m := range s.Messages {
+		if m.Role == llm.RoleUser {
+			n++
+		}
+	}
+	return n
+}
+
+// ErrNotFound is returned when no session matches the requested ID.
+var ErrNotFound = errors.New("session not found")
+
+// Store keeps sessions as JSON files in a directory.
+type Store struct {
+	dir string
+}
+
+// DefaultDir returns $XDG_DATA_HOME/oli/sessions, falling back to
+// ~/.local/share/oli/sessions.
+func DefaultDir() (string, error) {
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		return filepath.Join(xdg, "oli", "sessions"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("locate home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", "oli", "sessions"), nil
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// New creates an unsaved session for the given model and directory.
+func (s *Store) New(model, workDir string) *Session {
+	now := time.Now()
+	return &Session{
+		ID:        newID(now),
+		Model:     model,
+		WorkDir:   workDir,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Save writes the session to disk, replacing any previous version.
+func (s *Store) Save(sess *Session) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("create session dir: %w", err)
+	}
+
+	sess.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal session: %w", err)
+	}
+
+	// Write to a temp file first so a crash never leaves a truncated session
+	tmp, err := os.CreateTemp(s.dir, sess.ID+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write session: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write session: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write session: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(sess.ID)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write session: %w", err)
+	}
+	return nil
+}
+
+// Load reads a session by ID. A unique prefix of the ID is also accepted.
+func (s *Store) Load(id string) (*Session, error) {
+	id, err := s.resolve(id)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		return nil, fmt.Errorf("read session: %w", err)
+	}
+
+	var sess Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, fmt.Errorf("parse session %s: %w", id, err)
+	}
+	return &sess, nil
+}
+
+// List returns all stored sessions, most recently updated first.
+// Files that cannot be parsed are skipped.
+func (s *Store) List() ([]*Session, error) {
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []*Session
+	for _, id := range ids {
+		sess, err := s.Load(id)
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, sess)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, nil
+}
+
+// Remove deletes a session by ID or unique prefix and returns the full ID removed.
+func (s *Store) Remove(id string) (string, error) {
+	id, err := s.resolve(id)
+	if err != nil {
+		return "", err
+	}
+	if err := os.Remove(s.path(id)); err != nil {
+		return "", fmt.Errorf("remove session: %w", err)
+	}
+	return id, nil
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *Store) ids() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read session dir: %w", err)
+	}
+
+	var ids []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() && strings.HasSuffix(name, ".json") {
+			ids = append(ids, strings.TrimSuffix(name, ".json"))
+		}
+	}
+	return ids, nil
+}
+
+// resolve expands an ID prefix to the single matching session ID.
+func (s *Store) resolve(prefix string) (string, error) {
+	if prefix == "" || strings.ContainsAny(prefix, `/\`) {
+		return "", fmt.Errorf("%w: %q", ErrNotFound, prefix)
+	}
+
+	ids, err := s.ids()
+	if err != nil {
+		return "", err
+	}
+
+	var matches []string
+	for _, id := range ids {
+		if id == prefix {
+			return id, nil
+		}
+		if strings.HasPrefix(id, prefix) {
+			matches = append(matches, id)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: %q", ErrNotFound, prefix)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("ambiguous session id %q matches %d sessions", prefix, len(matches))
+	}
+}
+
+// newID builds a sortable, human-readable ID such as 20260131-154210-3fa2.
+func newID(t time.Time) string {
+	suffix := make([]byte, 2)
+	rand.Read(suffix)
+	return t.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
@@ -0,0 +1,36 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"ollama-cli/internal/llm"
+)
+
+func TestTitle(t *testing.T) {
+	long := strings.Repeat("ñ", 70)
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"¿Qué hace   main.go?\n", "¿Qué hace main.go?"},
+		{strings.Repeat("á", 60), strings.Repeat("á", 60)},
+		{long, strings.Repeat("ñ", 57) + "..."},
+		{"a" + long, "a" + strings.Repeat("ñ", 56) + "..."},
+	}
+	for _, tt := range tests {
+		s := &Session{Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: "sistema"},
+			{Role: llm.RoleUser, Content: tt.content},
+		}}
+		got := s.Title()
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("Title() = %q, want %q", got, tt.want)
+		}
+	}
+
+	if got := (&Session{}).Title(); got != "" {
+		t.Errorf("empty session: Title() = %q", got)
+	}
+}
@@ -0,0 +1,333 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Permission es el nivel de permiso de un comando
+type Permission int
+
+const (
+	PermAllow   Permission = iota // Se ejecuta sin preguntar
+	PermConfirm                   // Requiere confirmación del usuario
+	PermDeny                      // Nunca se ejecuta
+)
+
+func (p Permission) String() string {
+	switch p {
+	case PermAllow:
+		return "allow"
+	case PermConfirm:
+		return "confirm"
+	case PermDeny:
+		return "deny"
+	}
+	return fmt.Sprintf("Permission(%d)", int(p))
+}
+
+// CommandRule asocia un patrón con un permiso. El patrón es una lista de
+// palabras que deben coincidir con el inicio del comando; "*" coincide con
+// una palabra cualquiera y "**" con cualquier cantidad de palabras. Una
+// opción ("--output") también coincide con su forma "--output=valor".
+// Ejemplos: "git status", "go test", "find ** -delete".
+type CommandRule struct {
+	Pattern    string
+	Permission Permission
+}
+
+// CommandPolicy decide qué comandos se pueden ejecutar
+type CommandPolicy struct {
+	Rules   []CommandRule
+	Default Permission // Permiso si ninguna regla coincide
+}
+
+// NewCommandPolicy arma una política a partir de listas de patrones
+func NewCommandPolicy(allow, confirm, deny []string) CommandPolicy {
+	policy := CommandPolicy{Default: PermConfirm}
+	for _, p := range allow {
+		policy.Rules = append(policy.Rules, CommandRule{Pattern: p, Permission: PermAllow})
+	}
+	for _, p := range confirm {
+		policy.Rules = append(policy.Rules, CommandRule{Pattern: p, Permission: PermConfirm})
+	}
+	for _, p := range deny {
+		policy.Rules = append(policy.Rules, CommandRule{Pattern: p, Permission: PermDeny})
+	}
+	return policy
+}
+
+// Check devuelve el permiso para un comando ya separado en argumentos.
+// Gana la regla más específica (más palabras fijas); en empate, la más restrictiva.
+func (p CommandPolicy) Check(args []string) Permission {
+	if len(args) == 0 {
+		return PermDeny
+	}
+
+	// Comparar por nombre del programa, no por su ruta
+	words := append([]string{filepath.Base(args[0])}, args[1:]...)
+
+	best, bestLen := p.Default, -1
+	for _, rule := range p.Rules {
+		pattern := strings.Fields(rule.Pattern)
+		if len(pattern) == 0 || !matchPrefix(pattern, words) {
+			continue
+		}
+		specificity := 0
+		for _, w := range pattern {
+			if w != "**" {
+				specificity++
+			}
+		}
+		if specificity > bestLen || (specificity == bestLen && rule.Permission > best) {
+			best, bestLen = rule.Permission, specificity
+		}
+	}
+	return best
+}
+
+// matchPrefix indica si el patrón coincide con el inicio de words
+func matchPrefix(pattern, words []string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(words); i++ {
+			if matchPrefix(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(words) == 0 || !matchWord(pattern[0], words[0]) {
+		return false
+	}
+	return matchPrefix(pattern[1:], words[1:])
+}
+
+// matchWord compara una palabra del patrón con una del comando
+func matchWord(pattern, word string) bool {
+	if pattern == "*" || pattern == word {
+		return true
+	}
+	return strings.HasPrefix(pattern, "-") && strings.HasPrefix(word, pattern+"=")
+}
+
+// CommandOptions configura la ejecución de RunCommand
+type CommandOptions struct {
+	Policy    CommandPolicy
+	Dir       string        // Directorio de trabajo; vacío = actual
+	Timeout   time.Duration // 0 = sin límite propio (solo el del contexto)
+	MaxOutput int           // Bytes máximos por salida; 0 = sin recortar
+}
+
+// CommandResult es el resultado de un comando ejecutado
+type CommandResult struct {
+	Command   string
+	Stdout    string
+	Stderr    string
+	ExitCode  int
+	TimedOut  bool
+	Truncated bool
+}
+
+// String formatea el resultado para mostrarlo o enviarlo al modelo
+func (r CommandResult) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "$ %s\n", r.Command)
+	if r.Stdout != "" {
+		fmt.Fprintf(&sb, "[stdout]\n%s\n", strings.TrimRight(r.Stdout, "\n"))
+	}
+	if r.Stderr != "" {
+		fmt.Fprintf(&sb, "[stderr]\n%s\n", strings.TrimRight(r.Stderr, "\n"))
+	}
+	if r.TimedOut {
+		sb.WriteString("[tiempo límite agotado]\n")
+	}
+	fmt.Fprintf(&sb, "[exit code: %d]", r.ExitCode)
+	return sb.String()
+}
+
+// ErrCommandDenied se devuelve cuando la política prohíbe el comando
+var ErrCommandDenied = errors.New("comando no permitido por la política")
+
+// RunCommand ejecuta un comando aplicando la política de permisos.
+// El comando se ejecuta directamente, sin shell: no se admiten tuberías,
+// redirecciones ni encadenamiento. Un exit code distinto de cero no es un
+// error; se informa en el resultado.
+func RunCommand(ctx context.Context, command string, opts CommandOptions) (CommandResult, error) {
+	args, err := SplitCommand(command)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	if len(args) == 0 {
+		return CommandResult{}, fmt.Errorf("comando vacío")
+	}
+
+	question := fmt.Sprintf("¿Ejecutar '%s'?", command)
+	perm := opts.Policy.Check(args)
+	// Un comando permitido no puede leer fuera del proyecto sin preguntar
+	if perm == PermAllow {
+		if outside := outsideArg(args, opts.Dir); outside != "" {
+			perm = PermConfirm
+			question = fmt.Sprintf("¿Ejecutar '%s'? (accede a %s, fuera del proyecto)", command, outside)
+		}
+	}
+
+	switch perm {
+	case PermDeny:
+		return CommandResult{}, fmt.Errorf("%w: %s", ErrCommandDenied, command)
+	case PermConfirm:
+		if !AskConfirmation(question) {
+			return CommandResult{}, fmt.Errorf("operación cancelada por el usuario")
+		}
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = opts.Dir
+	cmd.WaitDelay = time.Second
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	result := CommandResult{Command: command}
+	err = cmd.Run()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.TimedOut = true
+		result.ExitCode = -1
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		return CommandResult{}, fmt.Errorf("ejecutar %s: %w", args[0], err)
+	}
+
+	var cut bool
+	result.Stdout, cut = truncateMiddle(stdout.String(), opts.MaxOutput)
+	result.Truncated = cut
+	result.Stderr, cut = truncateMiddle(stderr.String(), opts.MaxOutput)
+	result.Truncated = result.Truncated || cut
+
+	return result, nil
+}
+
+// truncateMiddle recorta s a max bytes conservando el inicio y el final,
+// donde suelen estar el comando y los errores.
+func truncateMiddle(s string, max int) (string, bool) {
+	if max <= 0 || len(s) <= max {
+		return s, false
+	}
+	head := CutUTF8(s, max/2)
+	start := len(s) - (max - max/2)
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++
+	}
+	omitted := start - len(head)
+	return fmt.Sprintf("%s\n[... %d bytes omitidos ...]\n%s", head, omitted, s[start:]), true
+}
+
+// outsideArg devuelve el primer argumento de args que es una ruta fuera
+// del espacio de trabajo, o "" si no hay ninguno. Se revisan también los
+// valores de flags como --file=/etc/passwd.
+func outsideArg(args []string, dir string) string {
+	for _, arg := range args[1:] {
+		path := arg
+		if strings.HasPrefix(arg, "-") {
+			_, value, ok := strings.Cut(arg, "=")
+			if !ok {
+				continue
+			}
+			path = value
+		}
+		if path == "" {
+			continue
+		}
+		if strings.HasPrefix(path, "~") {
+			return arg
+		}
+		if !filepath.IsAbs(path) && dir != "" {
+			path = filepath.Join(dir, path)
+		}
+		if _, err := Resolve(path); err != nil {
+			return arg
+		}
+	}
+	return ""
+}
+
+// SplitCommand separa una línea de comando en argumentos respetando comillas
+// simples, dobles y escapes con '\'. Rechaza operadores de shell sin comillas
+// porque RunCommand no usa un shell.
+func SplitCommand(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else if r == '\\' && quote == '"' && i+1 < len(runes) {
+				i++
+				current.WriteRune(runes[i])
+			} else {
+				current.WriteRune(r)
+			}
+
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+
+		case r == '\\':
+			if i+1 < len(runes) {
+				i++
+				current.WriteRune(runes[i])
+				inArg = true
+			}
+
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+
+		case strings.ContainsRune("|&;<>`$()", r):
+			return nil, fmt.Errorf("operador de shell no soportado %q (los comandos se ejecutan sin shell)", r)
+
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("comillas sin cerrar en el comando")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"testing"
+
+	"ollama-cli/internal/config"
+)
+
+func TestDefaultCommandPolicy(t *testing.T) {
+	cfg := config.Default()
+	policy := NewCommandPolicy(cfg.CommandAllow, cfg.CommandConfirm, cfg.CommandDeny)
+
+	tests := []struct {
+		command string
+		want    Permission
+	}{
+		{"ls -la", PermAllow},
+		{"/bin/cat main.go", PermAllow},
+		{"git status", PermAllow},
+		{"git diff --stat HEAD~1", PermAllow},
+		{"git log --oneline -5", PermAllow},
+		{"git branch --show-current", PermAllow},
+		{"git branch --list", PermAllow},
+
+		// Branch changes and writes through git are not read-only
+		{"git branch", PermConfirm},
+		{"git branch -D feature", PermConfirm},
+		{"git branch -d feature", PermConfirm},
+		{"git branch --delete feature", PermConfirm},
+		{"git branch -m old new", PermConfirm},
+		{"git branch -f main HEAD~3", PermConfirm},
+		{"git diff --output=patch.diff", PermConfirm},
+		{"git diff HEAD --output patch.diff", PermConfirm},
+		{"git log -p --output=/tmp/log", PermConfirm},
+		{"git commit -m x", PermConfirm},
+		{"find . -name x -delete", PermConfirm},
+		{"go test ./...", PermConfirm},
+		{"curl example.com", PermConfirm},
+
+		{"rm -rf /", PermDeny},
+		{"sudo ls", PermDeny},
+		{"git push origin main", PermDeny},
+		{"git reset --hard HEAD", PermDeny},
+	}
+	for _, tt := range tests {
+		args, err := SplitCommand(tt.command)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := policy.Check(args); got != tt.want {
+			t.Errorf("Check(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestMatchWord(t *testing.T) {
+	tests := []struct {
+		pattern, word string
+		want          bool
+	}{
+		{"*", "anything", true},
+		{"diff", "diff", true},
+		{"--output", "--output=f", true},
+		{"--output", "--output-directory=d", false},
+		{"diff", "diff=x", false},
+	}
+	for _, tt := range tests {
+		if got := matchWord(tt.pattern, tt.word); got != tt.want {
+			t.Errorf("matchWord(%q, %q) = %v, want %v", tt.pattern, tt.word, got, tt.want)
+		}
+	}
+}
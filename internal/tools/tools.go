@@ -3,41 +3,161 @@ package tools
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
+	"os/exec"
 	"strings"
+	"unicode/utf8"
+
+	"ollama-cli/internal/journal"
 )
 
+// writeJournal registra cada escritura para poder deshacerla (nil = sin registro)
+var writeJournal *journal.Journal
+
+// SetJournal activa el registro de escrituras en j
+func SetJournal(j *journal.Journal) {
+	writeJournal = j
+}
+
+// assumeYes hace que las preguntas se respondan solas (ver SetAssumeYes)
+var assumeYes bool
+
+// SetAssumeYes acepta todas las confirmaciones sin preguntar (--yes). Los
+// comandos que la política deniega siguen bloqueados.
+func SetAssumeYes(yes bool) {
+	assumeYes = yes
+}
+
+// promptOut recibe las preguntas al usuario (ver SetPromptOutput)
+var promptOut io.Writer = os.Stdout
+
+// SetPromptOutput envía las preguntas a w en lugar de la salida estándar,
+// por ejemplo a os.Stderr cuando la salida estándar es JSON.
+func SetPromptOutput(w io.Writer) {
+	promptOut = w
+}
+
 // AskConfirmation pregunta al usuario y espera confirmación
 func AskConfirmation(question string) bool {
+	if assumeYes {
+		fmt.Fprintf(os.Stderr, "\n %s (s, por --yes)\n", question)
+		return true
+	}
 	reader := bufio.NewReader(os.Stdin)
-	fmt.Printf("\n %s (s/n): ", question)
+	fmt.Fprintf(promptOut, "\n %s (s/n): ", question)
 	response, _ := reader.ReadString('\n')
 	response = strings.TrimSpace(strings.ToLower(response))
 	return response == "s" || response == "si" || response == "y" || response == "yes"
 }
 
+// AskChoice muestra una pregunta con opciones de una letra y devuelve la
+// elegida. Repite la pregunta hasta recibir una opción válida; si la entrada
+// se cierra devuelve la última opción (la más conservadora por convención).
+// Con SetAssumeYes devuelve la primera, que por convención es aceptar.
+func AskChoice(question string, options ...string) string {
+	if assumeYes {
+		fmt.Fprintf(os.Stderr, "\n %s: %s (por --yes)\n", question, options[0])
+		return options[0]
+	}
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Fprintf(promptOut, "\n %s: ", question)
+		response, err := reader.ReadString('\n')
+		response = strings.TrimSpace(strings.ToLower(response))
+		for _, opt := range options {
+			if response == opt {
+				return opt
+			}
+		}
+		if err != nil {
+			return options[len(options)-1]
+		}
+		fmt.Fprintf(promptOut, " Opciones: %s\n", strings.Join(options, ", "))
+	}
+}
+
+// AskInput pide una línea de texto al usuario
+func AskInput(question string) string {
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Fprintf(promptOut, " %s: ", question)
+	response, _ := reader.ReadString('\n')
+	return strings.TrimSpace(response)
+}
+
+// ColorEnabled indica si la salida estándar admite colores ANSI
+func ColorEnabled() bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// EditInEditor abre content en $VISUAL o $EDITOR (vi por defecto) y
+// devuelve el texto editado. ext se usa para que el editor reconozca el lenguaje.
+func EditInEditor(content, ext string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	tmp, err := os.CreateTemp("", "oli-*"+ext)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	// El editor puede venir con argumentos, por ejemplo "code --wait"
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], tmp.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %s: %w", parts[0], err)
+	}
+
+	edited, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(edited), nil
+}
+
 // ReadFile lee el contenido de un archivo
 func ReadFile(path string) (string, error) {
-	// Convertir a ruta absoluta si es relativa
-	if !filepath.IsAbs(path) {
-		wd, _ := os.Getwd()
-		path = filepath.Join(wd, path)
+	// Ruta absoluta y dentro del espacio de trabajo
+	path, err := Resolve(path)
+	if err != nil {
+		return "", err
 	}
 
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return "", err
 	}
+	rememberRead(path, content)
 	return string(content), nil
 }
 
 // WriteFile escribe contenido a un archivo (con confirmación)
 func WriteFile(path string, content string) error {
-	// Convertir a ruta absoluta si es relativa
-	if !filepath.IsAbs(path) {
-		wd, _ := os.Getwd()
-		path = filepath.Join(wd, path)
+	// Ruta absoluta y dentro del espacio de trabajo
+	path, err := Resolve(path)
+	if err != nil {
+		return err
 	}
 
 	// Verificar si existe
@@ -55,39 +175,28 @@ func WriteFile(path string, content string) error {
 		return fmt.Errorf("operación cancelada por el usuario")
 	}
 
-	// Crear directorio si no existe
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
-	}
-
-	return os.WriteFile(path, []byte(content), 0644)
+	return writeFile(path, content)
 }
 
 // WriteFileDirectly escribe sin pedir confirmación (ya se pidió antes)
 func WriteFileDirectly(path string, content string) error {
-	if !filepath.IsAbs(path) {
-		wd, _ := os.Getwd()
-		path = filepath.Join(wd, path)
-	}
-
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	path, err := Resolve(path)
+	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(path, []byte(content), 0644)
+	return writeFile(path, content)
 }
 
 // ListDir lista archivos en un directorio
 func ListDir(path string) ([]string, error) {
-	if path == "" || path == "." {
-		path, _ = os.Getwd()
+	if path == "" {
+		path = "."
 	}
 
-	if !filepath.IsAbs(path) {
-		wd, _ := os.Getwd()
-		path = filepath.Join(wd, path)
+	path, err := Resolve(path)
+	if err != nil {
+		return nil, err
 	}
 
 	entries, err := os.ReadDir(path)
@@ -105,3 +214,15 @@ func ListDir(path string) ([]string, error) {
 	}
 	return files, nil
 }
+
+// CutUTF8 devuelve el prefijo más largo de s de hasta n bytes sin partir
+// un carácter multibyte
+func CutUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
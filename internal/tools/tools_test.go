@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCutUTF8(t *testing.T) {
+	s := "añb" // ñ is two bytes
+	for n, want := range map[int]string{0: "", 1: "a", 2: "a", 3: "añ", 10: "añb"} {
+		if got := CutUTF8(s, n); got != want {
+			t.Errorf("CutUTF8(%q, %d) = %q, want %q", s, n, got, want)
+		}
+	}
+}
+
+func TestTruncateMiddleKeepsRunes(t *testing.T) {
+	s := "ñññññññññ" // 18 bytes
+	got, cut := truncateMiddle(s, 7)
+	if !cut {
+		t.Fatal("not truncated")
+	}
+	if !utf8.ValidString(got) {
+		t.Errorf("truncateMiddle produced invalid UTF-8: %q", got)
+	}
+}
+
+func TestAskConfirmationPromptOutput(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteString("s\n")
+	w.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+
+	var out strings.Builder
+	SetPromptOutput(&out)
+	defer SetPromptOutput(os.Stdout)
+
+	if !AskConfirmation("¿Guardar a.go?") {
+		t.Error("AskConfirmation() = false, want true")
+	}
+	if out.String() != "\n ¿Guardar a.go? (s/n): " {
+		t.Errorf("prompt = %q", out.String())
+	}
+}
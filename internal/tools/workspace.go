@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// workspaceRoot es la raíz (ya resuelta) fuera de la cual no se lee ni se
+// escribe. Vacía = sin restricción.
+var workspaceRoot string
+
+// allowOutside desactiva la restricción de forma explícita
+var allowOutside bool
+
+// ErrOutsideWorkspace se devuelve al acceder a una ruta fuera de la raíz
+var ErrOutsideWorkspace = errors.New("ruta fuera del espacio de trabajo")
+
+// SetWorkspace fija la raíz del espacio de trabajo. Los enlaces simbólicos
+// se resuelven para comparar rutas reales.
+func SetWorkspace(root string) error {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return err
+	}
+	workspaceRoot = resolved
+	return nil
+}
+
+// WorkspaceRoot devuelve la raíz actual del espacio de trabajo
+func WorkspaceRoot() string {
+	return workspaceRoot
+}
+
+// AllowOutsideWorkspace permite (o vuelve a prohibir) rutas fuera de la raíz
+func AllowOutsideWorkspace(allow bool) {
+	allowOutside = allow
+}
+
+// Resolve convierte path en una ruta absoluta con los enlaces simbólicos
+// resueltos y verifica que esté dentro del espacio de trabajo. Las rutas
+// relativas se toman desde el directorio actual. La ruta devuelta es la que
+// se debe usar para leer o escribir, de modo que lo verificado sea lo que
+// realmente se toca.
+func Resolve(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(wd, path)
+	}
+	path = filepath.Clean(path)
+
+	resolved, err := resolveExisting(path)
+	if err != nil {
+		return "", err
+	}
+
+	if workspaceRoot == "" || allowOutside || within(workspaceRoot, resolved) {
+		return resolved, nil
+	}
+	return "", fmt.Errorf("%w: %s (raíz: %s)", ErrOutsideWorkspace, path, workspaceRoot)
+}
+
+// resolveExisting resuelve los enlaces simbólicos de la parte existente de
+// path; el resto (archivos o carpetas aún no creados) se agrega tal cual.
+func resolveExisting(path string) (string, error) {
+	var missing []string
+	current := path
+	for {
+		resolved, err := filepath.EvalSymlinks(current)
+		if err == nil {
+			parts := append([]string{resolved}, missing...)
+			return filepath.Join(parts...), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		// Un enlace roto no existe para EvalSymlinks pero escribir en él
+		// crearía su destino, que podría estar fuera de la raíz
+		if info, lerr := os.Lstat(current); lerr == nil && info.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("enlace simbólico roto: %s", current)
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return path, nil
+		}
+		missing = append([]string{filepath.Base(current)}, missing...)
+		current = parent
+	}
+}
+
+func within(root, path string) bool {
+	if path == root {
+		return true
+	}
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
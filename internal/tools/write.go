@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ollama-cli/internal/atomicfile"
+)
+
+// ErrModifiedOnDisk se devuelve al intentar escribir un archivo que cambió
+// en disco desde la última vez que oli lo leyó.
+var ErrModifiedOnDisk = errors.New("el archivo cambió en disco desde que oli lo leyó")
+
+// fileStamp identifica la versión de un archivo que oli leyó o escribió
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	sum     [sha256.Size]byte
+}
+
+var (
+	stampsMu sync.Mutex
+	stamps   = make(map[string]fileStamp)
+)
+
+// RememberRead registra que el modelo vio content como el contenido de path
+// por otra vía que ReadFile (por ejemplo, el contexto del proyecto), para
+// que writeFile detecte si el archivo cambió después.
+func RememberRead(path string, content []byte) {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	rememberRead(path, content)
+}
+
+// rememberRead guarda la versión leída de path para detectar cambios posteriores
+func rememberRead(path string, content []byte) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	stampsMu.Lock()
+	defer stampsMu.Unlock()
+	stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size(), sum: sha256.Sum256(content)}
+}
+
+// checkUnchanged verifica que path siga igual a como oli lo vio por última vez.
+// Si oli nunca lo leyó no hay nada que comparar.
+func checkUnchanged(path string) error {
+	stampsMu.Lock()
+	stamp, ok := stamps[path]
+	stampsMu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s fue eliminado", ErrModifiedOnDisk, path)
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(stamp.modTime) && info.Size() == stamp.size {
+		return nil
+	}
+
+	// La fecha cambió; solo es un conflicto si también cambió el contenido
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if sha256.Sum256(current) != stamp.sum {
+		return fmt.Errorf("%w: %s (vuelve a leerlo antes de escribir)", ErrModifiedOnDisk, path)
+	}
+	return nil
+}
+
+// writeFile escribe path de forma atómica: registra el estado anterior en
+// el historial, conserva permisos y finales de línea del original, escribe
+// en un archivo temporal del mismo directorio y lo renombra encima.
+// Si no se puede registrar en el historial no se escribe: toda escritura
+// debe poder deshacerse.
+func writeFile(path string, content string) error {
+	if err := checkUnchanged(path); err != nil {
+		return err
+	}
+
+	mode := fs.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if original, err := os.ReadFile(path); err == nil && usesCRLF(original) {
+			content = toCRLF(content)
+		}
+	}
+	data := []byte(content)
+
+	if writeJournal != nil {
+		if err := writeJournal.Record(path, data); err != nil {
+			return fmt.Errorf("registrar en el historial: %w", err)
+		}
+	}
+
+	// Crear directorio si no existe
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if err := atomicfile.Write(path, data, mode); err != nil {
+		return err
+	}
+	rememberRead(path, data)
+	return nil
+}
+
+// usesCRLF indica si la mayoría de las líneas terminan en \r\n
+func usesCRLF(data []byte) bool {
+	crlf := bytes.Count(data, []byte("\r\n"))
+	lf := bytes.Count(data, []byte("\n"))
+	return crlf > 0 && crlf*2 >= lf
+}
+
+// toCRLF normaliza los finales de línea a \r\n
+func toCRLF(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
@@ -0,0 +1,31 @@
+package tools
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteDetectsChangeAfterContextRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.go")
+	os.WriteFile(path, []byte("package a\n"), 0644)
+
+	// The file reached the model through the project context
+	RememberRead(path, []byte("package a\n"))
+
+	// The user edits it before the answer is saved
+	later := time.Now().Add(time.Second)
+	os.WriteFile(path, []byte("package a // edited\n"), 0644)
+	os.Chtimes(path, later, later)
+
+	resolved, _ := filepath.EvalSymlinks(path)
+	if err := writeFile(resolved, "package b\n"); !errors.Is(err, ErrModifiedOnDisk) {
+		t.Fatalf("writeFile() = %v, want ErrModifiedOnDisk", err)
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != "package a // edited\n" {
+		t.Errorf("file was overwritten: %q", got)
+	}
+}